@@ -0,0 +1,79 @@
+package analyze
+
+import (
+	"regexp"
+	"videorepack/mkv"
+)
+
+// Policies for the tracks that most viewers don't want, such as commentary or audio description
+const (
+	PolicyKeep = "keep"
+	PolicyDrop = "drop"
+)
+
+var commentaryNamePattern = regexp.MustCompile(`(?i)\b(commentary|comentarios?|commentaire|kommentar|audiokommentar)\b`)
+
+// Names of stereo versions of the main audio, which the channel heuristic must not mistake for
+// commentary
+var stereoNamePattern = regexp.MustCompile(`(?i)\b(stereo|estéreo|2\.0|downmix|dolby surround)\b`)
+
+// CommentaryTrack is a track detected as commentary.
+type CommentaryTrack struct {
+	Track *mkv.ExtractedTrack
+	// Set when the track name marks the track as commentary, otherwise it was detected by its
+	// channel count against Reference
+	Name      string
+	Reference *mkv.ExtractedTrack
+}
+
+// IsCommentaryName reports whether a track name marks a commentary track.
+func IsCommentaryName(name string) bool {
+	return commentaryNamePattern.MatchString(name)
+}
+
+// DetectCommentary flags the commentary audio and subtitle tracks from their track name. With
+// byChannels, a mono or stereo audio track that follows a surround track in the same language is
+// flagged too, unless its name marks it as a stereo version.
+func DetectCommentary(tracks []mkv.ExtractedTrack, byChannels bool) []CommentaryTrack {
+	var detected []CommentaryTrack
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Info.Type != "audio" && t.Info.Type != "subtitles" || t.Info.Properties.FlagCommentary {
+			continue
+		}
+		if IsCommentaryName(t.Info.Properties.TrackName) {
+			t.Info.Properties.FlagCommentary = true
+			detected = append(detected, CommentaryTrack{Track: t, Name: t.Info.Properties.TrackName})
+		}
+	}
+
+	if !byChannels {
+		return detected
+	}
+
+	// The first audio track of each language is the main one
+	main := map[string]*mkv.ExtractedTrack{}
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Info.Type != "audio" || t.Info.Properties.FlagCommentary {
+			continue
+		}
+		lang := t.Info.Properties.LanguageIETF.String()
+		if lang == "" || lang == "und" {
+			continue
+		}
+
+		reference, ok := main[lang]
+		if !ok {
+			main[lang] = t
+			continue
+		}
+		if reference.Info.Channels() > 2 && t.Info.Channels() > 0 && t.Info.Channels() <= 2 &&
+			!stereoNamePattern.MatchString(t.Info.Properties.TrackName) {
+			t.Info.Properties.FlagCommentary = true
+			detected = append(detected, CommentaryTrack{Track: t, Reference: reference})
+		}
+	}
+
+	return detected
+}
@@ -0,0 +1,51 @@
+package analyze
+
+import (
+	"testing"
+	"videorepack/mkv"
+)
+
+func TestDetectCommentary(t *testing.T) {
+	spanish, _ := mkv.FromIETFName("es-ES")
+	english, _ := mkv.FromIETFName("en")
+	audio := func(id int, lang mkv.LocaleInfo, channels int, name string) mkv.ExtractedTrack {
+		return mkv.ExtractedTrack{Info: mkv.Track{ID: id, Type: "audio", Properties: mkv.TrackProperties{
+			CodecID:              "A_AC3",
+			LanguageIETF:         lang,
+			TrackName:            name,
+			AudioTrackProperties: mkv.AudioTrackProperties{AudioChannels: channels},
+		}}}
+	}
+
+	newTracks := func() []mkv.ExtractedTrack {
+		return []mkv.ExtractedTrack{
+			audio(1, english, 6, ""),
+			audio(2, english, 2, "Director's Commentary"),
+			audio(3, english, 2, ""),
+			audio(4, spanish, 6, "Castellano"),
+			audio(5, spanish, 2, "Castellano Estéreo"),
+			{Info: mkv.Track{ID: 6, Type: "subtitles", Properties: mkv.TrackProperties{TrackName: "Comentario del director"}}},
+		}
+	}
+
+	tracks := newTracks()
+	detected := DetectCommentary(tracks, false)
+	if len(detected) != 2 || detected[0].Track.Info.ID != 2 || detected[1].Track.Info.ID != 6 {
+		t.Fatalf("unexpected name detection: %+v", detected)
+	}
+	if !tracks[1].Info.Properties.FlagCommentary || tracks[2].Info.Properties.FlagCommentary {
+		t.Error("expected only the named tracks to be flagged")
+	}
+
+	tracks = newTracks()
+	detected = DetectCommentary(tracks, true)
+	if len(detected) != 3 {
+		t.Fatalf("expected 3 commentary tracks, got %+v", detected)
+	}
+	if d := detected[2]; d.Track.Info.ID != 3 || d.Name != "" || d.Reference.Info.ID != 1 {
+		t.Errorf("unexpected channel detection: %+v", d)
+	}
+	if tracks[4].Info.Properties.FlagCommentary {
+		t.Error("stereo version flagged as commentary")
+	}
+}
@@ -0,0 +1,31 @@
+package analyze
+
+import (
+	"regexp"
+	"videorepack/mkv"
+)
+
+// "AD" is only taken in capitals, as a word it's too common in other languages
+var audioDescriptionNamePattern = regexp.MustCompile(`(?i:\b(audio ?descripci[oó]n|audio ?description|audio ?described|descriptive( audio| video)?|described video|dvs|visually impaired|invidentes|audiodeskription|audiodescription)\b)|\bAD\b`)
+
+// IsAudioDescriptionName reports whether a track name marks an audio description track.
+func IsAudioDescriptionName(name string) bool {
+	return audioDescriptionNamePattern.MatchString(name)
+}
+
+// DetectAudioDescription sets the visual impaired flag on the audio tracks whose name marks them as
+// audio description, and returns them.
+func DetectAudioDescription(tracks []mkv.ExtractedTrack) []*mkv.ExtractedTrack {
+	var detected []*mkv.ExtractedTrack
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Info.Type != "audio" || t.Info.Properties.FlagVisualImpaired {
+			continue
+		}
+		if IsAudioDescriptionName(t.Info.Properties.TrackName) {
+			t.Info.Properties.FlagVisualImpaired = true
+			detected = append(detected, t)
+		}
+	}
+	return detected
+}
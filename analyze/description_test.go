@@ -0,0 +1,19 @@
+package analyze
+
+import "testing"
+
+func TestIsAudioDescriptionName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Audiodescripción":          true,
+		"Castellano (AD)":           true,
+		"English Descriptive Audio": true,
+		"Audio Description":         true,
+		"Castellano":                false,
+		"Ad Astra":                  false,
+		"Dolby Digital 5.1":         false,
+	} {
+		if got := IsAudioDescriptionName(name); got != want {
+			t.Errorf("%q: expected %v, got %v", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package analyze
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"videorepack/fonts"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FontCheck lists the fonts used by an ASS subtitle track and those not attached to the container.
+type FontCheck struct {
+	Track   *mkv.ExtractedTrack
+	Fonts   []string
+	Missing []string
+}
+
+// FontIndex maps lowercase font names to the files that provide them.
+type FontIndex map[string]string
+
+func (fi FontIndex) Lookup(font string) (string, bool) {
+	path, ok := fi[strings.ToLower(font)]
+	return path, ok
+}
+
+// Add indexes the names of a font file. Names already indexed keep their file.
+func (fi FontIndex) Add(path string) {
+	names, err := fonts.ReadNames(path)
+	if err != nil {
+		log.Debugf("Skipping font %s: %v", path, err)
+		return
+	}
+	for _, name := range names {
+		if _, ok := fi[strings.ToLower(name)]; !ok {
+			fi[strings.ToLower(name)] = path
+		}
+	}
+}
+
+// IsFontAttachment reports whether an attachment is a TrueType or OpenType font.
+func IsFontAttachment(a *mkv.ExtractedAttachment) bool {
+	contentType := strings.ToLower(a.Info.ContentType)
+	return fonts.IsFontFile(a.Info.FileName) || strings.Contains(contentType, "font") ||
+		strings.Contains(contentType, "opentype") || strings.Contains(contentType, "truetype")
+}
+
+// AttachedFonts indexes the fonts attached to a container.
+func AttachedFonts(attachments []mkv.ExtractedAttachment) FontIndex {
+	index := FontIndex{}
+	for i := range attachments {
+		if IsFontAttachment(&attachments[i]) {
+			index.Add(attachments[i].FilePath)
+		}
+	}
+	return index
+}
+
+// IndexFontsDir indexes the font files of a directory and its subdirectories.
+func IndexFontsDir(dir string) (FontIndex, error) {
+	index := FontIndex{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && fonts.IsFontFile(path) {
+			index.Add(path)
+		}
+		return nil
+	})
+	return index, err
+}
+
+// CheckFonts lists the fonts of every ASS subtitle track and the ones missing from the index of
+// attached fonts.
+func CheckFonts(tracks []mkv.ExtractedTrack, attached FontIndex) []FontCheck {
+	var checks []FontCheck
+	for i := range tracks {
+		t := &tracks[i]
+		if format, ok := subtitles.FormatFromCodecID(t.Info.Properties.CodecID); !ok || (format != subtitles.FormatASS && format != subtitles.FormatSSA) {
+			continue
+		}
+
+		sub, err := subtitles.ParseFile(t.FilePath)
+		if err != nil {
+			log.Debugf("Skipping subtitle track %d in font check: %v", t.Info.ID, err)
+			continue
+		}
+
+		check := FontCheck{Track: t, Fonts: sub.Fonts()}
+		for _, font := range check.Fonts {
+			if _, ok := attached.Lookup(font); !ok {
+				check.Missing = append(check.Missing, font)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// FontAttachment returns a font file as an attachment for the container.
+func FontAttachment(path string) mkv.ExtractedAttachment {
+	return mkv.ExtractedAttachment{
+		Info: mkv.Attachment{
+			ContentType: fonts.MIMEType(path),
+			FileName:    filepath.Base(path),
+		},
+		FilePath: path,
+	}
+}
+
+// FontUsage returns the characters drawn with each font by the ASS subtitle tracks, keyed by the
+// lowercase font name. It fails when an ASS track can't be read, as the fonts it uses are unknown.
+func FontUsage(tracks []mkv.ExtractedTrack) (map[string][]rune, error) {
+	usage := make(map[string][]rune)
+	for i := range tracks {
+		t := &tracks[i]
+		if format, ok := subtitles.FormatFromCodecID(t.Info.Properties.CodecID); !ok || (format != subtitles.FormatASS && format != subtitles.FormatSSA) {
+			continue
+		}
+
+		sub, err := subtitles.ParseFile(t.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("subtitle track %d: %v", t.Info.ID, err)
+		}
+		for font, chars := range sub.FontChars() {
+			usage[font] = append(usage[font], chars...)
+		}
+	}
+	return usage, nil
+}
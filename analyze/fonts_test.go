@@ -0,0 +1,59 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"videorepack/mkv"
+)
+
+const fontUsageSample = `[Script Info]
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,0,2,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,ab
+`
+
+func TestFontUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.ass")
+	if err := os.WriteFile(path, []byte(fontUsageSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ass := func(id int, path string) mkv.ExtractedTrack {
+		return mkv.ExtractedTrack{FilePath: path, Info: mkv.Track{ID: id, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/ASS"}}}
+	}
+
+	usage, err := FontUsage([]mkv.ExtractedTrack{ass(2, path)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(usage["arial"]); got != "ab" {
+		t.Errorf("expected characters \"ab\" for arial, got %q", got)
+	}
+
+	// Tracks with the legacy codec ID share the fonts too
+	legacyPath := filepath.Join(t.TempDir(), "legacy.ass")
+	if err := os.WriteFile(legacyPath, []byte(strings.Replace(fontUsageSample, ",,ab", ",,cd", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	legacy := ass(3, legacyPath)
+	legacy.Info.Properties.CodecID = "S_ASS"
+	usage, err = FontUsage([]mkv.ExtractedTrack{ass(2, path), legacy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(usage["arial"]); got != "abcd" {
+		t.Errorf("expected characters \"abcd\" for arial, got %q", got)
+	}
+
+	// The fonts of an unreadable track are unknown, subsetting the others could break it
+	if _, err := FontUsage([]mkv.ExtractedTrack{ass(2, path), ass(3, filepath.Join(t.TempDir(), "missing.ass"))}); err == nil {
+		t.Error("expected an error for an unreadable ASS track")
+	}
+}
@@ -0,0 +1,179 @@
+package analyze
+
+import (
+	"time"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// A track is forced when it has at most this fraction of the lines of the full track...
+	forcedMaxEventRatio = 0.5
+	// ...and shows text at most this fraction of the time. Typesetting splits signs in many short
+	// lines, so the coverage is the stronger hint.
+	forcedMaxCoverageRatio = 0.25
+	// ...and, within its span, shows text at most this fraction of the time the full track does.
+	// Signs are sparse, a track as dense as the full one is a cut version of it.
+	forcedMaxDensityRatio = 0.5
+	// Tracks with a shorter span are too short to tell a cut track from a sparse one
+	forcedMinDensitySpan = 2 * time.Minute
+	// Full tracks shorter than this are too small to compare with
+	forcedMinReferenceEvents = 50
+)
+
+// SubtitleStats summarizes the dialogue of a text or PGS subtitle track.
+type SubtitleStats struct {
+	Events   int
+	Coverage time.Duration // Time with text on screen
+	Span     time.Duration // From the first to the last line
+}
+
+// Stats parses the extracted file of a text or PGS subtitle track.
+func Stats(t *mkv.ExtractedTrack) (SubtitleStats, error) {
+	if t.Info.Properties.CodecID == "S_HDMV/PGS" {
+		return pgsStats(t)
+	}
+
+	sub, err := subtitles.ParseFile(t.FilePath)
+	if err != nil {
+		return SubtitleStats{}, err
+	}
+
+	var stats SubtitleStats
+	dialogue := sub.Dialogue()
+	stats.Events = len(dialogue)
+	stats.Coverage = sub.Coverage()
+	if len(dialogue) > 0 {
+		first, last := dialogue[0].Start, dialogue[0].End
+		for _, e := range dialogue {
+			first = min(first, e.Start)
+			last = max(last, e.End)
+		}
+		stats.Span = last - first
+	}
+	return stats, nil
+}
+
+func pgsStats(t *mkv.ExtractedTrack) (SubtitleStats, error) {
+	captions, err := subtitles.ReadPGS(t.FilePath)
+	if err != nil {
+		return SubtitleStats{}, err
+	}
+
+	stats := SubtitleStats{Events: len(captions), Coverage: subtitles.CaptionCoverage(captions)}
+	if len(captions) > 0 {
+		// Captions are in presentation order and don't overlap
+		stats.Span = captions[len(captions)-1].End - captions[0].Start
+	}
+	return stats, nil
+}
+
+// hasStats reports whether the dialogue of a subtitle track can be read.
+func hasStats(t *mkv.ExtractedTrack) bool {
+	_, ok := subtitles.FormatFromCodecID(t.Info.Properties.CodecID)
+	return ok || t.Info.Properties.CodecID == "S_HDMV/PGS"
+}
+
+// ForcedTrack is a subtitle track detected as forced by comparing it with the full track of the
+// same language.
+type ForcedTrack struct {
+	Track     *mkv.ExtractedTrack
+	Reference *mkv.ExtractedTrack
+	Stats     SubtitleStats
+	Full      SubtitleStats // Stats of the reference track
+}
+
+func (f *ForcedTrack) EventRatio() float64 {
+	return float64(f.Stats.Events) / float64(f.Full.Events)
+}
+
+func (f *ForcedTrack) CoverageRatio() float64 {
+	return float64(f.Stats.Coverage) / float64(f.Full.Coverage)
+}
+
+// DensityRatio compares the time with text on screen within the span of each track.
+func (f *ForcedTrack) DensityRatio() float64 {
+	if f.Stats.Span == 0 || f.Full.Span == 0 {
+		return 0
+	}
+	density := float64(f.Stats.Coverage) / float64(f.Stats.Span)
+	return density / (float64(f.Full.Coverage) / float64(f.Full.Span))
+}
+
+// sparse reports whether the lines of the track are spread like signs rather than like dialogue.
+func (f *ForcedTrack) sparse() bool {
+	return f.Stats.Span < forcedMinDensitySpan || f.DensityRatio() <= forcedMaxDensityRatio
+}
+
+// DetectForced looks for unflagged forced subtitles among the text and PGS subtitle tracks of each
+// language: the track with the most lines is taken as the full one, and the tracks that cover a
+// small fraction of its dialogue, spread over their span, are marked as forced. Languages that
+// already have a forced track are left as they are.
+func DetectForced(tracks []mkv.ExtractedTrack) []ForcedTrack {
+	byLanguage := map[string][]int{}
+	var languages []string
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Info.Type != "subtitles" {
+			continue
+		}
+		lang := t.Info.Properties.LanguageIETF.String()
+		if _, ok := byLanguage[lang]; !ok {
+			languages = append(languages, lang)
+		}
+		byLanguage[lang] = append(byLanguage[lang], i)
+	}
+
+	var detected []ForcedTrack
+	for _, lang := range languages {
+		indexes := byLanguage[lang]
+		if len(indexes) < 2 || hasForced(tracks, indexes) {
+			continue
+		}
+
+		stats := map[int]SubtitleStats{}
+		reference := -1
+		for _, i := range indexes {
+			if !hasStats(&tracks[i]) {
+				continue
+			}
+			s, err := Stats(&tracks[i])
+			if err != nil {
+				log.Debugf("Skipping subtitle track %d in forced detection: %v", tracks[i].Info.ID, err)
+				continue
+			}
+			stats[i] = s
+			if reference == -1 || s.Events > stats[reference].Events {
+				reference = i
+			}
+		}
+		if reference == -1 || stats[reference].Events < forcedMinReferenceEvents || stats[reference].Coverage == 0 {
+			continue
+		}
+
+		for _, i := range indexes {
+			s, ok := stats[i]
+			if !ok || i == reference {
+				continue
+			}
+			candidate := ForcedTrack{Track: &tracks[i], Reference: &tracks[reference], Stats: s, Full: stats[reference]}
+			if s.Events > 0 && candidate.EventRatio() <= forcedMaxEventRatio && candidate.CoverageRatio() <= forcedMaxCoverageRatio && candidate.sparse() {
+				tracks[i].Info.Properties.ForcedTrack = true
+				detected = append(detected, candidate)
+			}
+		}
+	}
+
+	return detected
+}
+
+func hasForced(tracks []mkv.ExtractedTrack, indexes []int) bool {
+	for _, i := range indexes {
+		if tracks[i].Info.Properties.ForcedTrack {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package analyze
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"videorepack/mkv"
+)
+
+// Intervals between the lines of the test subtitles
+const (
+	dialogueEvery = 10 * time.Second
+	signsEvery    = 4 * time.Minute
+)
+
+// writeSRT writes a subtitle with n lines of two seconds, one at each interval.
+func writeSRT(t *testing.T, name string, n int, every time.Duration) string {
+	timestamp := func(d time.Duration) string {
+		s := int(d / time.Second)
+		return fmt.Sprintf("%02d:%02d:%02d,000", s/3600, s/60%60, s%60)
+	}
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		start := time.Duration(i) * every
+		fmt.Fprintf(&sb, "%d\n%s --> %s\nLínea %d\n\n", i+1, timestamp(start), timestamp(start+2*time.Second), i)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func subtitleTrack(t *testing.T, id int, lang string, lines int, every time.Duration) mkv.ExtractedTrack {
+	tag, err := mkv.FromIETFName(lang)
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := mkv.ExtractedTrack{FilePath: writeSRT(t, fmt.Sprintf("%d.srt", id), lines, every)}
+	track.Info.ID = id
+	track.Info.Type = "subtitles"
+	track.Info.Properties.CodecID = "S_TEXT/UTF8"
+	track.Info.Properties.LanguageIETF = tag
+	return track
+}
+
+// writeSUP writes a PGS stream with n captions of two seconds, one at each interval.
+func writeSUP(t *testing.T, name string, n int, every time.Duration) string {
+	var data []byte
+	for i := 0; i < n; i++ {
+		start := i * int(every/time.Millisecond)
+		for _, c := range []struct{ ms, objects int }{{start, 1}, {start + 2000, 0}} {
+			payload := []byte{0x07, 0x80, 0x04, 0x38, 0x10, 0, 1, 0x80, 0, 0, byte(c.objects)}
+			payload = append(payload, make([]byte, 8*c.objects)...)
+			data = append(data, "PG"...)
+			data = binary.BigEndian.AppendUint32(data, uint32(c.ms*90))
+			data = binary.BigEndian.AppendUint32(data, 0)
+			data = append(data, 0x16)
+			data = binary.BigEndian.AppendUint16(data, uint16(len(payload)))
+			data = append(data, payload...)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectForced(t *testing.T) {
+	tracks := []mkv.ExtractedTrack{
+		subtitleTrack(t, 2, "es-ES", 300, dialogueEvery),
+		subtitleTrack(t, 3, "es-ES", 12, signsEvery),
+		subtitleTrack(t, 4, "en", 300, dialogueEvery),
+		subtitleTrack(t, 5, "en", 250, dialogueEvery),
+	}
+
+	detected := DetectForced(tracks)
+	if len(detected) != 1 {
+		t.Fatalf("expected 1 forced track, got %d", len(detected))
+	}
+	if detected[0].Track.Info.ID != 3 || detected[0].Reference.Info.ID != 2 {
+		t.Errorf("unexpected detection: track %d, reference %d", detected[0].Track.Info.ID, detected[0].Reference.Info.ID)
+	}
+	if detected[0].Stats.Events != 12 || detected[0].Full.Events != 300 {
+		t.Errorf("unexpected stats: %+v / %+v", detected[0].Stats, detected[0].Full)
+	}
+	if !tracks[1].Info.Properties.ForcedTrack || tracks[0].Info.Properties.ForcedTrack || tracks[3].Info.Properties.ForcedTrack {
+		t.Error("unexpected forced flags")
+	}
+}
+
+func TestDetectForcedSkipsCutTracks(t *testing.T) {
+	// As dense as the full track, but only its first five minutes
+	tracks := []mkv.ExtractedTrack{
+		subtitleTrack(t, 2, "es-ES", 300, dialogueEvery),
+		subtitleTrack(t, 3, "es-ES", 30, dialogueEvery),
+	}
+
+	if detected := DetectForced(tracks); len(detected) != 0 {
+		t.Errorf("expected no detection, got %+v", detected[0].Stats)
+	}
+}
+
+func TestDetectForcedKeepsFlaggedLanguages(t *testing.T) {
+	tracks := []mkv.ExtractedTrack{
+		subtitleTrack(t, 2, "es-ES", 300, dialogueEvery),
+		subtitleTrack(t, 3, "es-ES", 12, signsEvery),
+		subtitleTrack(t, 4, "es-ES", 10, signsEvery),
+	}
+	tracks[2].Info.Properties.ForcedTrack = true
+
+	if detected := DetectForced(tracks); len(detected) != 0 {
+		t.Errorf("expected no detection, got %d", len(detected))
+	}
+}
+
+func TestDetectForcedPGS(t *testing.T) {
+	tracks := []mkv.ExtractedTrack{
+		subtitleTrack(t, 2, "es-ES", 300, dialogueEvery),
+		subtitleTrack(t, 3, "es-ES", 12, signsEvery),
+	}
+	tracks[1].FilePath = writeSUP(t, "3.sup", 12, signsEvery)
+	tracks[1].Info.Properties.CodecID = "S_HDMV/PGS"
+
+	detected := DetectForced(tracks)
+	if len(detected) != 1 || detected[0].Track.Info.ID != 3 {
+		t.Fatalf("expected the PGS track to be forced, got %+v", detected)
+	}
+	if detected[0].Stats.Events != 12 || detected[0].Stats.Coverage != 24*time.Second {
+		t.Errorf("unexpected stats: %+v", detected[0].Stats)
+	}
+}
@@ -0,0 +1,295 @@
+package analyze
+
+import (
+	"embed"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+)
+
+// Sample dialogue of the languages told apart by their trigrams, one file per language
+//
+//go:embed ngrams/*.txt
+var ngramSamples embed.FS
+
+// Languages written in their own script are recognized by it, checked in order. Scripts shared by
+// several languages, such as Cyrillic for Russian, Ukrainian or Bulgarian, have no language and
+// give no guess.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, ""}, // Chinese, Cantonese and the kanji of Japanese
+	{unicode.Cyrillic, ""},
+	{unicode.Arabic, ""}, // Arabic, Persian, Urdu...
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+}
+
+const (
+	LanguageSuggest  = "suggest"  // Only report the detected language
+	LanguageApply    = "apply"    // Tag the tracks without language
+	LanguageOverride = "override" // Also retag the tracks whose content contradicts their tag
+)
+
+const (
+	// Texts with fewer letters are too short to guess their language
+	languageMinLetters = 200
+	// Trigrams kept in each language profile
+	languageProfileSize = 400
+)
+
+type ngramProfile map[string]float64
+
+var languageProfiles = loadLanguageProfiles()
+
+func loadLanguageProfiles() map[string]ngramProfile {
+	entries, err := ngramSamples.ReadDir("ngrams")
+	if err != nil {
+		panic(err)
+	}
+
+	profiles := make(map[string]ngramProfile)
+	for _, entry := range entries {
+		data, err := ngramSamples.ReadFile(path.Join("ngrams", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		profiles[strings.TrimSuffix(entry.Name(), ".txt")] = newProfile(string(data), languageProfileSize)
+	}
+	return profiles
+}
+
+// newProfile returns the relative frequencies of the most common trigrams of the text, with
+// words padded with spaces so the start and end of words are trigrams too.
+func newProfile(text string, size int) ngramProfile {
+	counts := make(map[string]int)
+	total := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+			total++
+		}
+	}
+
+	trigrams := make([]string, 0, len(counts))
+	for t := range counts {
+		trigrams = append(trigrams, t)
+	}
+	sort.Slice(trigrams, func(i, j int) bool {
+		if counts[trigrams[i]] != counts[trigrams[j]] {
+			return counts[trigrams[i]] > counts[trigrams[j]]
+		}
+		return trigrams[i] < trigrams[j]
+	})
+	if size > 0 && len(trigrams) > size {
+		trigrams = trigrams[:size]
+	}
+
+	profile := make(ngramProfile, len(trigrams))
+	for _, t := range trigrams {
+		profile[t] = float64(counts[t]) / float64(total)
+	}
+	return profile
+}
+
+// similarity is the cosine similarity of two profiles.
+func (p ngramProfile) similarity(other ngramProfile) float64 {
+	var dot, normP, normO float64
+	for t, f := range p {
+		dot += f * other[t]
+		normP += f * f
+	}
+	for _, f := range other {
+		normO += f * f
+	}
+	if normP == 0 || normO == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normP*normO)
+}
+
+// LanguageGuess is the language of a text, as an ISO 639-1 code, and how sure the guess is, from
+// 0 to 1. An empty language means the text is too short or unknown.
+type LanguageGuess struct {
+	Language   string
+	Confidence float64
+}
+
+// GuessLanguage detects the language of a plain text. Languages with their own script are
+// recognized by it, and Latin script languages by comparing the text trigrams with the profiles of
+// the known languages. The confidence grows with the distance to the second best language.
+func GuessLanguage(text string) LanguageGuess {
+	letters := 0
+	scripts := make(map[*unicode.RangeTable]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				scripts[s.script]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return LanguageGuess{}
+	}
+
+	// Japanese is mostly written in kanji, a few kana are enough to tell it from Chinese
+	if kana := scripts[unicode.Hiragana] + scripts[unicode.Katakana]; kana*10 >= letters {
+		n := kana + scripts[unicode.Han]
+		return LanguageGuess{Language: "ja", Confidence: min(1, float64(n)/float64(letters))}
+	}
+	for _, s := range scriptLanguages {
+		if n := scripts[s.script]; n*2 >= letters {
+			if s.language == "" {
+				return LanguageGuess{}
+			}
+			return LanguageGuess{Language: s.language, Confidence: min(1, float64(n)/float64(letters))}
+		}
+	}
+
+	if letters < languageMinLetters {
+		return LanguageGuess{}
+	}
+
+	sample := newProfile(text, 0)
+	best, second := "", ""
+	scores := make(map[string]float64)
+	for lang, profile := range languageProfiles {
+		scores[lang] = sample.similarity(profile)
+		if best == "" || scores[lang] > scores[best] || scores[lang] == scores[best] && lang < best {
+			best, second = lang, best
+		} else if second == "" || scores[lang] > scores[second] {
+			second = lang
+		}
+	}
+	if scores[best] == 0 {
+		return LanguageGuess{}
+	}
+
+	// Related languages like Spanish and Galician score close to each other, a relative margin of a
+	// fifth is a sure guess
+	margin := (scores[best] - scores[second]) / scores[best]
+	return LanguageGuess{Language: best, Confidence: min(1, margin*5)}
+}
+
+// canGuess reports whether GuessLanguage can return a language, so a track tagged with it can be
+// told apart from the others.
+func canGuess(base language.Base) bool {
+	if _, ok := languageProfiles[base.String()]; ok {
+		return true
+	}
+	for _, s := range scriptLanguages {
+		if s.language == base.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// Dialogue beyond this length doesn't improve the guess
+const languageMaxSample = 20000
+
+// TrackLanguage guesses the language of a text subtitle track from its dialogue.
+func TrackLanguage(t *mkv.ExtractedTrack) (LanguageGuess, error) {
+	sub, err := subtitles.ParseFile(t.FilePath)
+	if err != nil {
+		return LanguageGuess{}, err
+	}
+
+	var sb strings.Builder
+	for _, e := range sub.Dialogue() {
+		if sb.Len() > languageMaxSample {
+			break
+		}
+		sb.WriteString(sub.PlainText(e))
+		sb.WriteString("\n")
+	}
+	return GuessLanguage(sb.String()), nil
+}
+
+// LanguageDetection is the language guessed for a subtitle track that has no language or whose
+// language doesn't match the content.
+type LanguageDetection struct {
+	Track       *mkv.ExtractedTrack
+	Previous    mkv.LocaleInfo
+	Guess       LanguageGuess
+	Suggested   mkv.LocaleInfo // Tag for the guessed language
+	Applied     bool
+	Contradicts bool // The track was tagged with another language
+}
+
+// DetectLanguages guesses the language of the text subtitle tracks. In apply mode the tracks
+// without language are tagged when the guess is confident enough, and in override mode also the
+// ones whose tag contradicts the content. Tagged tracks are reported only for confident guesses and
+// when their language is one the guess could have returned, e.g. never for Dutch or Ukrainian.
+// The suggested tag is the first preferred tag of the guessed language, e.g. es-ES for Spanish.
+func DetectLanguages(tracks []mkv.ExtractedTrack, mode string, minConfidence float64, preferred []mkv.LocaleInfo) []LanguageDetection {
+	var detected []LanguageDetection
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Info.Type != "subtitles" {
+			continue
+		}
+		if _, ok := subtitles.FormatFromCodecID(t.Info.Properties.CodecID); !ok {
+			continue
+		}
+
+		guess, err := TrackLanguage(t)
+		if err != nil {
+			log.Debugf("Skipping subtitle track %d in language detection: %v", t.Info.ID, err)
+			continue
+		}
+		if guess.Language == "" {
+			continue
+		}
+		base, err := language.ParseBase(guess.Language)
+		if err != nil {
+			continue
+		}
+
+		d := LanguageDetection{Track: t, Previous: t.Info.Properties.LanguageIETF, Guess: guess}
+		d.Suggested = mkv.LocaleInfo{Tag: language.Make(guess.Language)}
+		for _, p := range preferred {
+			if b, _ := p.Base(); b == base {
+				d.Suggested = p
+				break
+			}
+		}
+
+		confident := guess.Confidence >= minConfidence
+		tag := t.Info.Properties.LanguageIETF.String()
+		if tag == "" || tag == "und" {
+			d.Applied = confident && (mode == LanguageApply || mode == LanguageOverride)
+		} else {
+			if b, _ := t.Info.Properties.LanguageIETF.Base(); b == base || !confident || !canGuess(b) {
+				continue
+			}
+			d.Contradicts = true
+			d.Applied = mode == LanguageOverride
+		}
+
+		if d.Applied {
+			t.Info.Properties.LanguageIETF = d.Suggested
+		}
+		detected = append(detected, d)
+	}
+
+	return detected
+}
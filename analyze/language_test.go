@@ -0,0 +1,111 @@
+package analyze
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"videorepack/mkv"
+)
+
+var languageSamples = map[string]string{
+	"es": "Mira, no sé qué pasó anoche, pero tenemos que hablar con tu padre antes de que vuelva a casa. Él no va a entender nada si se lo cuentas así, de golpe, sin explicarle primero por qué lo hicimos. Además, mañana tengo que trabajar temprano y no puedo quedarme despierto toda la noche.",
+	"gl": "Mira, non sei que pasou onte á noite, pero temos que falar co teu pai antes de que volva á casa. El non vai entender nada se llo contas así, de golpe, sen explicarlle primeiro por que o fixemos. Ademais, mañá teño que traballar cedo e non podo quedar esperto toda a noite.",
+	"pt": "Olha, não sei o que aconteceu ontem à noite, mas temos que falar com o seu pai antes que ele volte para casa. Ele não vai entender nada se você contar assim, de repente, sem explicar primeiro por que fizemos isso. Além disso, amanhã tenho que trabalhar cedo e não posso ficar acordado a noite toda.",
+	"en": "Look, I don't know what happened last night, but we need to talk to your father before he comes back home. He won't understand anything if you tell him like that, all at once, without explaining first why we did it. Besides, tomorrow I have to work early and I can't stay up all night.",
+	"ca": "Mira, no sé què va passar ahir a la nit, però hem de parlar amb el teu pare abans que torni a casa. Ell no entendrà res si li ho expliques així, de cop, sense explicar-li primer per què ho vam fer. A més, demà he de treballar d'hora i no em puc quedar despert tota la nit.",
+	"ja": "お前、昨日の夜何があったのか知らないけど、お父さんが帰ってくる前に話さなきゃ。",
+}
+
+// Samples of languages GuessLanguage can't tell apart
+var otherSamples = map[string]string{
+	"uk": "Слухай, я не знаю, що сталося вчора ввечері, але нам треба поговорити з твоїм батьком, перш ніж він повернеться додому.",
+	"nl": "Kijk, ik weet niet wat er gisteravond is gebeurd, maar we moeten met je vader praten voordat hij thuiskomt. Hij zal er niets van begrijpen als je het hem zo vertelt, ineens, zonder eerst uit te leggen waarom we het deden. Bovendien moet ik morgen vroeg werken en kan ik niet de hele nacht opblijven.",
+}
+
+func TestGuessLanguage(t *testing.T) {
+	for lang, text := range languageSamples {
+		if guess := GuessLanguage(text); guess.Language != lang || guess.Confidence < 0.5 {
+			t.Errorf("%s: unexpected guess %+v", lang, guess)
+		}
+	}
+
+	if guess := GuessLanguage("Hola, ¿qué tal?"); guess.Language != "" {
+		t.Errorf("expected no guess for a short text, got %+v", guess)
+	}
+	// Cyrillic is shared by Russian, Ukrainian, Bulgarian...
+	if guess := GuessLanguage(otherSamples["uk"]); guess.Language != "" {
+		t.Errorf("expected no guess for a shared script, got %+v", guess)
+	}
+}
+
+func writeDialogue(t *testing.T, name string, text string) string {
+	var sb strings.Builder
+	for i, line := range strings.SplitAfter(text, ". ") {
+		fmt.Fprintf(&sb, "%d\n00:00:%02d,000 --> 00:00:%02d,500\n%s\n\n", i+1, i*2, i*2+1, line)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectLanguages(t *testing.T) {
+	und, _ := mkv.FromIETFName("und")
+	english, _ := mkv.FromIETFName("en")
+	galician, _ := mkv.FromIETFName("gl")
+	spain, _ := mkv.FromIETFName("es-ES")
+
+	newTracks := func() []mkv.ExtractedTrack {
+		return []mkv.ExtractedTrack{
+			{FilePath: writeDialogue(t, "2.srt", languageSamples["es"]), Info: mkv.Track{ID: 2, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/UTF8", LanguageIETF: und}}},
+			{FilePath: writeDialogue(t, "3.srt", languageSamples["gl"]), Info: mkv.Track{ID: 3, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/UTF8", LanguageIETF: english}}},
+			{FilePath: writeDialogue(t, "4.srt", languageSamples["gl"]), Info: mkv.Track{ID: 4, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/UTF8", LanguageIETF: galician}}},
+		}
+	}
+
+	tracks := newTracks()
+	detected := DetectLanguages(tracks, LanguageApply, 0.5, []mkv.LocaleInfo{spain})
+	if len(detected) != 2 {
+		t.Fatalf("expected 2 detections, got %d", len(detected))
+	}
+	if d := detected[0]; d.Track.Info.ID != 2 || !d.Applied || d.Contradicts || d.Suggested != spain {
+		t.Errorf("unexpected detection for the und track: %+v", d)
+	}
+	if d := detected[1]; d.Track.Info.ID != 3 || d.Applied || !d.Contradicts || d.Suggested.String() != "gl" {
+		t.Errorf("unexpected detection for the mistagged track: %+v", d)
+	}
+	if tracks[0].Info.Properties.LanguageIETF != spain || tracks[1].Info.Properties.LanguageIETF != english {
+		t.Error("unexpected languages after apply")
+	}
+
+	tracks = newTracks()
+	DetectLanguages(tracks, LanguageSuggest, 0.5, nil)
+	if tracks[0].Info.Properties.LanguageIETF != und {
+		t.Error("suggest mode must not change the tags")
+	}
+
+	tracks = newTracks()
+	DetectLanguages(tracks, LanguageOverride, 0.5, nil)
+	if tracks[0].Info.Properties.LanguageIETF.String() != "es" || tracks[1].Info.Properties.LanguageIETF != galician {
+		t.Error("unexpected languages after override")
+	}
+}
+
+func TestDetectLanguagesUnknownTags(t *testing.T) {
+	var tracks []mkv.ExtractedTrack
+	for i, lang := range []string{"uk", "nl"} {
+		tag, _ := mkv.FromIETFName(lang)
+		tracks = append(tracks, mkv.ExtractedTrack{
+			FilePath: writeDialogue(t, lang+".srt", otherSamples[lang]),
+			Info:     mkv.Track{ID: i, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/UTF8", LanguageIETF: tag}},
+		})
+	}
+
+	if detected := DetectLanguages(tracks, LanguageOverride, 0, nil); len(detected) != 0 {
+		t.Errorf("expected no contradiction for languages without profile, got %+v", detected[0])
+	}
+}
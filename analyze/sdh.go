@@ -0,0 +1,89 @@
+package analyze
+
+import (
+	"regexp"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Minimum fraction of lines with sound descriptions or speaker labels of an SDH track. Music
+	// notes count half, as normal subtitles also mark song lyrics with them.
+	sdhMinCueRatio = 0.08
+	sdhMinCues     = 5
+)
+
+var sdhNamePattern = regexp.MustCompile(`(?i)\b(sdh|cc|hoh|closed captions?|hearing impaired|(para )?sordos|sourds et malentendants)\b`)
+
+// SDHTrack is a subtitle track detected as hearing impaired.
+type SDHTrack struct {
+	Track *mkv.ExtractedTrack
+	// Set when the track name marks the track as SDH, otherwise the content was analyzed
+	Name         string
+	Events       int
+	Descriptions int
+	Speakers     int
+	Music        int
+}
+
+func (s *SDHTrack) CueRatio() float64 {
+	if s.Events == 0 {
+		return 0
+	}
+	return (float64(s.Descriptions+s.Speakers) + float64(s.Music)/2) / float64(s.Events)
+}
+
+// IsSDHName reports whether a track name marks hearing impaired subtitles.
+func IsSDHName(name string) bool {
+	return sdhNamePattern.MatchString(name)
+}
+
+// DetectSDH flags the hearing impaired subtitle tracks, from their track name or from the sound
+// descriptions, speaker labels and music notes of text subtitles.
+func DetectSDH(tracks []mkv.ExtractedTrack) []SDHTrack {
+	var detected []SDHTrack
+	for i := range tracks {
+		t := &tracks[i]
+		if t.Info.Type != "subtitles" || t.Info.Properties.FlagHearingImpaired {
+			continue
+		}
+
+		if IsSDHName(t.Info.Properties.TrackName) {
+			t.Info.Properties.FlagHearingImpaired = true
+			detected = append(detected, SDHTrack{Track: t, Name: t.Info.Properties.TrackName})
+			continue
+		}
+
+		if _, ok := subtitles.FormatFromCodecID(t.Info.Properties.CodecID); !ok {
+			continue
+		}
+		sub, err := subtitles.ParseFile(t.FilePath)
+		if err != nil {
+			log.Debugf("Skipping subtitle track %d in SDH detection: %v", t.Info.ID, err)
+			continue
+		}
+
+		result := SDHTrack{Track: t}
+		for _, e := range sub.Dialogue() {
+			cues := sub.FindHearingCues(e)
+			result.Events++
+			switch {
+			case cues.Descriptions:
+				result.Descriptions++
+			case cues.Speakers:
+				result.Speakers++
+			case cues.Music:
+				result.Music++
+			}
+		}
+
+		if result.Descriptions+result.Speakers >= sdhMinCues && result.CueRatio() >= sdhMinCueRatio {
+			t.Info.Properties.FlagHearingImpaired = true
+			detected = append(detected, result)
+		}
+	}
+
+	return detected
+}
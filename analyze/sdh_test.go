@@ -0,0 +1,64 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"videorepack/mkv"
+)
+
+const sdhSample = `1
+00:00:01,000 --> 00:00:02,000
+[THUNDER RUMBLING]
+
+2
+00:00:03,000 --> 00:00:04,000
+JOHN: Who's there?
+
+3
+00:00:05,000 --> 00:00:06,000
+(DOOR CREAKS)
+
+4
+00:00:07,000 --> 00:00:08,000
+Nobody.
+
+5
+00:00:09,000 --> 00:00:10,000
+[FOOTSTEPS]
+
+6
+00:00:11,000 --> 00:00:12,000
+MARY: Run!
+
+7
+00:00:13,000 --> 00:00:14,000
+♪ Ominous music ♪
+`
+
+func TestDetectSDH(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sdh.srt")
+	if err := os.WriteFile(path, []byte(sdhSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := []mkv.ExtractedTrack{
+		{FilePath: path, Info: mkv.Track{ID: 2, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/UTF8"}}},
+		{FilePath: writeSRT(t, "full.srt", 100, dialogueEvery), Info: mkv.Track{ID: 3, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_TEXT/UTF8"}}},
+		{Info: mkv.Track{ID: 4, Type: "subtitles", Properties: mkv.TrackProperties{CodecID: "S_HDMV/PGS", TrackName: "English (SDH)"}}},
+	}
+
+	detected := DetectSDH(tracks)
+	if len(detected) != 2 {
+		t.Fatalf("expected 2 SDH tracks, got %d", len(detected))
+	}
+	if d := detected[0]; d.Track.Info.ID != 2 || d.Events != 7 || d.Descriptions != 3 || d.Speakers != 2 || d.Music != 1 {
+		t.Errorf("unexpected content detection: %+v", d)
+	}
+	if d := detected[1]; d.Track.Info.ID != 4 || d.Name != "English (SDH)" {
+		t.Errorf("unexpected name detection: %+v", d)
+	}
+	if !tracks[0].Info.Properties.FlagHearingImpaired || tracks[1].Info.Properties.FlagHearingImpaired || !tracks[2].Info.Properties.FlagHearingImpaired {
+		t.Error("unexpected hearing impaired flags")
+	}
+}
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
+	"videorepack/analyze"
+	"videorepack/config"
 	"videorepack/ffmpeg"
+	"videorepack/fonts"
 	"videorepack/mkv"
 	"videorepack/naming"
+	"videorepack/report"
+	"videorepack/tools"
+	"videorepack/transcode"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -18,38 +28,377 @@ const Name = "videorepack"
 func main() {
 	log.SetLevel(log.TraceLevel)
 
-	if len(os.Args) < 2 {
-		log.Fatalf("Uso: %s <input.mkv>", Name)
+	configPath := flag.String("config", os.Getenv("VIDEOREPACK_CONFIG"), "Fichero de configuración JSON")
+	dryRun := flag.Bool("dry-run", false, "Muestra los comandos que se ejecutarían sin modificar ningún fichero")
+	recordPath := flag.String("record", "", "Guarda las invocaciones de herramientas externas en un fichero JSON")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		log.Fatalf("Uso: %s [-config <config.json>] [-dry-run] [-record <fichero.json>] <input.mkv> | encoders | doctor", Name)
+	}
+	input := flag.Arg(0)
+
+	cfg := config.Default()
+	if *configPath != "" {
+		var err error
+		cfg, err = config.Load(*configPath)
+		if err != nil {
+			log.Fatalf("Error cargando la configuración: %v", err)
+		}
+	}
+
+	if err := mkv.SetPatchRules(cfg.TrackPatchRules()); err != nil {
+		log.Fatalf("Error en las reglas de corrección de pistas: %v", err)
+	}
+
+	tools.Configure(cfg.Tools)
+	var executor tools.Executor = tools.LocalExecutor{}
+	if cfg.Container != nil {
+		executor = tools.NewContainerExecutor(*cfg.Container, executor)
+	}
+	if *recordPath != "" {
+		recorder := &tools.RecordingExecutor{Next: executor}
+		defer func() {
+			if err := recorder.Save(*recordPath); err != nil {
+				log.Errorf("Error guardando las invocaciones: %v", err)
+			}
+		}()
+		executor = recorder
+	}
+	if *dryRun {
+		executor = tools.DryRunExecutor{Next: executor}
+	}
+	tools.SetExecutor(executor)
+
+	status := tools.Check()
+
+	var caps *ffmpeg.Capabilities
+	if _, ok := status.Tools[tools.FFmpeg]; ok {
+		var err error
+		caps, err = ffmpeg.DetectCapabilities()
+		if err != nil {
+			log.Warnf("No se pudieron detectar los codificadores de ffmpeg: %v", err)
+		}
+	}
+
+	switch input {
+	case "doctor":
+		printDoctor(status)
+		fmt.Println()
+		printEncoders(cfg, caps)
+		if status.Fatal() {
+			os.Exit(1)
+		}
+		return
+	case "encoders":
+		printEncoders(cfg, caps)
+		return
 	}
 
-	if strings.Index(os.Args[1], "*") == -1 {
-		_ = convertVideo(os.Args[1])
-	} else {
+	for _, p := range status.Problems {
+		if p.Optional {
+			log.Warnf("%s no disponible: %s", p.Feature, describeProblem(p))
+		} else {
+			log.Errorf("%s no disponible: %s", p.Feature, describeProblem(p))
+		}
+	}
+	if status.Fatal() {
+		log.Fatalf("Faltan herramientas necesarias, ejecuta '%s doctor' para más detalles", Name)
+	}
+	checkEncoders(cfg, caps)
+
+	runReport := &report.Report{}
+	defer func() {
+		fmt.Println("Resumen de cambios:")
+		runReport.Print(os.Stdout)
+	}()
+
+	matches := []string{input}
+	if strings.Contains(input, "*") {
 		// Walk files that match input pattern
-		matches, err := filepath.Glob(os.Args[1])
+		var err error
+		matches, err = filepath.Glob(input)
 		if err != nil {
 			log.Fatalf("Error al buscar archivos: %v", err)
 		}
 		if len(matches) == 0 {
-			log.Fatalf("No se encontraron archivos que coincidan con el patrón: %s", os.Args[1])
+			log.Fatalf("No se encontraron archivos que coincidan con el patrón: %s", input)
 		}
+	}
 
-		for _, file := range matches {
-			log.Infof("Procesando archivo: %s", file)
-			err := convertVideo(file)
-			if err != nil {
-				log.Errorf("Error al convertir %s: %v", file, err)
+	for _, file := range matches {
+		log.Infof("Procesando archivo: %s", file)
+		fileReport := runReport.File(file)
+		if err := convertVideo(file, cfg, caps, fileReport); err != nil {
+			log.Errorf("Error al convertir %s: %v", file, err)
+			fileReport.Add("error", "%v", err)
+		}
+	}
+}
+
+// printDoctor reports the external tools found and the features that can't be used.
+func printDoctor(status *tools.Status) {
+	fmt.Println("Herramientas:")
+	for _, name := range tools.All {
+		if tool, ok := status.Tools[name]; ok {
+			fmt.Printf("  %-12s %-10s %s\n", name, tool.Version.String(), tool.Path)
+		} else {
+			fmt.Printf("  %-12s NO ENCONTRADO (%v). Se puede indicar la ruta con %s o en la configuración\n", name, status.Errors[name], tools.EnvVar(name))
+		}
+	}
+
+	fmt.Println("Requisitos:")
+	if len(status.Problems) == 0 {
+		fmt.Println("  Todos los requisitos se cumplen")
+	}
+	for _, p := range status.Problems {
+		level := "ERROR"
+		if p.Optional {
+			level = "AVISO"
+		}
+		fmt.Printf("  %-5s %s: %s\n", level, p.Feature, describeProblem(p))
+	}
+}
+
+func describeProblem(p tools.Problem) string {
+	if p.Missing {
+		return fmt.Sprintf("no se encuentra %s", p.Tool)
+	}
+	return fmt.Sprintf("requiere %s >= %s, encontrado %s", p.Tool, p.Min.String(), p.Found.String())
+}
+
+// printEncoders shows which conversion policies can run with the local ffmpeg build.
+func printEncoders(cfg *config.Config, caps *ffmpeg.Capabilities) {
+	status := func(encoder string) string {
+		if caps.HasEncoder(encoder) && caps.Encoders[encoder].Experimental {
+			return "experimental, no se usa"
+		} else if caps.HasEncoder(encoder) {
+			return "disponible"
+		}
+		return "NO DISPONIBLE"
+	}
+
+	fmt.Println("Conversión de audio:")
+	for _, rule := range cfg.AudioRules() {
+		fmt.Printf("  %s\n", rule.String())
+		for _, encoder := range rule.Encoders {
+			fmt.Printf("    %-12s %s\n", encoder, status(encoder))
+		}
+		if encoder, ok := caps.SelectEncoder(rule.Encoders); ok {
+			fmt.Printf("    => se usará %s\n", encoder)
+		} else {
+			fmt.Printf("    => no se puede aplicar, se mantendrá la pista original\n")
+		}
+	}
+
+	fmt.Println("Recodificación de vídeo:")
+	for _, rule := range cfg.VideoTranscode {
+		fmt.Printf("  %s (crf %d, preset %s): %s\n", rule.Profile.Encoder, rule.Profile.CRF, rule.Profile.Preset, status(rule.Profile.Encoder))
+	}
+}
+
+// checkEncoders warns at startup about conversion policies that can't run with the local ffmpeg build.
+func checkEncoders(cfg *config.Config, caps *ffmpeg.Capabilities) {
+	for _, rule := range cfg.AudioRules() {
+		if _, ok := caps.SelectEncoder(rule.Encoders); !ok {
+			log.Warnf("Ningún codificador disponible para la regla de audio %s", rule.String())
+		}
+	}
+	for _, rule := range cfg.VideoTranscode {
+		if !caps.HasEncoder(rule.Profile.Encoder) {
+			log.Warnf("Codificador de vídeo %s no disponible, no se recodificará el vídeo", rule.Profile.Encoder)
+		}
+	}
+}
+
+// sdhWithoutFullTrack returns the SDH text subtitle tracks of the languages that have no other
+// complete subtitle track.
+func sdhWithoutFullTrack(tracks []mkv.ExtractedTrack) []mkv.ExtractedTrack {
+	var result []mkv.ExtractedTrack
+	for i := range tracks {
+		t := &tracks[i]
+		if !t.Info.Properties.FlagHearingImpaired || !transcode.IsTextSubtitle(t) {
+			continue
+		}
+		hasFull := slices.ContainsFunc(tracks, func(other mkv.ExtractedTrack) bool {
+			return other.Info.Type == "subtitles" && other.Info.Properties.LanguageIETF == t.Info.Properties.LanguageIETF &&
+				!other.Info.Properties.FlagHearingImpaired && !other.Info.Properties.ForcedTrack
+		})
+		if !hasFull {
+			result = append(result, *t)
+		}
+	}
+	return result
+}
+
+// orderTracks applies the ordering policy to the output tracks, logging the reason of each position.
+func orderTracks(policy mkv.TrackOrder, tracks []mkv.ExtractedTrack, mainLang mkv.LocaleInfo, originalLang mkv.LocaleInfo) []int {
+	var order []int
+	log.Info("Orden de las pistas:")
+	for i, rank := range policy.Rank(tracks, mainLang, originalLang) {
+		t := rank.Track
+		var reasons []string
+		switch rank.Language {
+		case mkv.RankMain:
+			reasons = append(reasons, "idioma principal")
+		case mkv.RankOriginal:
+			reasons = append(reasons, "idioma original")
+		case mkv.RankPreferred:
+			reasons = append(reasons, fmt.Sprintf("idioma preferido n.º %d", rank.Preference+1))
+		default:
+			if t.Info.Properties.LanguageIETF.String() == "und" {
+				reasons = append(reasons, "sin idioma")
+			} else {
+				reasons = append(reasons, "otros idiomas, por orden alfabético")
+			}
+		}
+		if rank.BaseMatch {
+			reasons = append(reasons, "por idioma base, tras las etiquetas exactas")
+		}
+		if rank.Forced && policy.ForcedFirst {
+			reasons = append(reasons, "forzados antes que completos")
+		} else if rank.Forced {
+			reasons = append(reasons, "forzados después de completos")
+		}
+		if rank.SDH {
+			reasons = append(reasons, "SDH al final")
+		}
+		if rank.Supplementary {
+			reasons = append(reasons, "comentarios o audiodescripción al final")
+		}
+		log.Infof("  %d. Pista %s %d (%s): %s", i+1, t.Info.Type, t.Info.ID, t.Info.Properties.LanguageIETF.String(), strings.Join(reasons, ", "))
+		order = append(order, t.Info.ID)
+	}
+	return order
+}
+
+// collectFonts reports the fonts used by ASS subtitles that aren't attached, and attaches the ones
+// found in the fonts directory.
+func collectFonts(tracks []mkv.ExtractedTrack, attachments []mkv.ExtractedAttachment, fontsDir string, fileReport *report.File) []mkv.ExtractedAttachment {
+	attached := analyze.AttachedFonts(attachments)
+	var local analyze.FontIndex
+
+	for _, check := range analyze.CheckFonts(tracks, attached) {
+		log.Debugf("Fuentes de la pista de subtítulos %d: %s", check.Track.Info.ID, strings.Join(check.Fonts, ", "))
+		for _, font := range check.Missing {
+			if _, ok := attached.Lookup(font); ok {
+				// Added for a previous track
+				continue
+			}
+
+			if local == nil && fontsDir != "" {
+				var err error
+				if local, err = analyze.IndexFontsDir(fontsDir); err != nil {
+					log.Warnf("Error leyendo el directorio de fuentes: %v", err)
+				}
+			}
+
+			if path, ok := local.Lookup(font); ok {
+				log.Infof("Añadiendo fuente %s desde <%s>", font, path)
+				fileReport.Add("fonts", "pista %d: añadida la fuente %s (%s)", check.Track.Info.ID, font, filepath.Base(path))
+				attachments = append(attachments, analyze.FontAttachment(path))
+				attached.Add(path)
+			} else {
+				log.Warnf("La fuente %s de la pista de subtítulos %d no está incluida en el fichero", font, check.Track.Info.ID)
+				fileReport.Add("fonts", "pista %d: falta la fuente %s", check.Track.Info.ID, font)
+			}
+		}
+	}
+
+	return attachments
+}
+
+// subsetFonts rewrites the font attachments used by ASS subtitles with only the glyphs they draw.
+// Fonts no subtitle uses are kept as they are. It returns the new files.
+func subsetFonts(tracks []mkv.ExtractedTrack, attachments []mkv.ExtractedAttachment, fileReport *report.File) []string {
+	usage, err := analyze.FontUsage(tracks)
+	if err != nil {
+		// Subsetting could remove glyphs the unreadable track draws
+		log.Warnf("No se reducen las fuentes, no se pueden leer todos los subtítulos ASS: %v", err)
+		fileReport.Add("fonts", "fuentes sin reducir: %v", err)
+		return nil
+	}
+
+	var files []string
+	var saved int
+	for i := range attachments {
+		a := &attachments[i]
+		if !analyze.IsFontAttachment(a) {
+			continue
+		}
+
+		names, err := fonts.ReadNames(a.FilePath)
+		if err != nil {
+			log.Warnf("Error leyendo la fuente %s: %v", a.Info.FileName, err)
+			continue
+		}
+		var chars []rune
+		for _, name := range names {
+			chars = append(chars, usage[strings.ToLower(name)]...)
+		}
+		if len(chars) == 0 {
+			continue
+		}
+
+		targetFilePath, before, after, err := transcode.SubsetFont(a, chars)
+		if errors.Is(err, fonts.ErrUnsupported) {
+			log.Warnf("No se puede reducir la fuente %s: %v", a.Info.FileName, err)
+			fileReport.Add("fonts", "fuente %s sin reducir: formato no soportado", a.Info.FileName)
+			continue
+		} else if err != nil {
+			log.Warnf("Error al reducir la fuente %s: %v. Se mantiene la fuente original.", a.Info.FileName, err)
+			continue
+		}
+
+		files = append(files, targetFilePath)
+		saved += before - after
+		log.Infof("Fuente %s reducida de %s a %s", a.Info.FileName, formatSize(before), formatSize(after))
+		fileReport.Add("fonts", "fuente %s reducida de %s a %s", a.Info.FileName, formatSize(before), formatSize(after))
+	}
+
+	if saved > 0 {
+		fileReport.Add("fonts", "espacio ahorrado en fuentes: %s", formatSize(saved))
+	}
+	return files
+}
+
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// deleteSidecars removes the sidecar subtitle files merged into the output, once the output is verified.
+func deleteSidecars(outputFile string, output mkv.ExtractedContainer) {
+	if err := mkv.Verify(outputFile, output); err != nil {
+		log.Warnf("No se eliminan los subtítulos externos, el fichero de salida no es correcto: %v", err)
+		return
+	}
+
+	for _, t := range output.Tracks {
+		if t.Sidecar == "" {
+			continue
+		}
+		// Los subtítulos VobSub incluyen el fichero .sub junto al índice
+		for _, f := range append([]string{t.Sidecar}, t.ExtraFiles...) {
+			log.Infof("Eliminando subtítulo externo <%s>", f)
+			if err := os.Remove(f); err != nil {
+				log.Warnf("Error eliminando subtítulo externo: %v", err)
 			}
 		}
 	}
 }
 
-func convertVideo(input string) error {
+func convertVideo(input string, cfg *config.Config, caps *ffmpeg.Capabilities, fileReport *report.File) error {
 	outputPath := path.Join(filepath.Dir(input), "repacked")
 	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
 		err := os.Mkdir(outputPath, 0755)
 		if err != nil {
-			log.Fatalf("Error creando directorio de salida: %v", err)
+			return fmt.Errorf("error creando directorio de salida: %v", err)
 		}
 	}
 
@@ -64,28 +413,179 @@ func convertVideo(input string) error {
 	log.Infof("Extrayendo pistas...")
 	extracted, err := mkv.ExtractAll(input, "")
 	if err != nil {
-		log.Fatalf("Error extrayendo pistas: %v", err)
+		return fmt.Errorf("error extrayendo pistas: %v", err)
 	}
 	for _, t := range extracted.Tracks {
-		filesToDelete = append(filesToDelete, t.FilePath)
+		filesToDelete = append(filesToDelete, t.Files()...)
 		if len(t.TimeMapPath) > 0 {
 			filesToDelete = append(filesToDelete, t.TimeMapPath)
 		}
 	}
+	for _, a := range extracted.Attachments {
+		filesToDelete = append(filesToDelete, a.FilePath)
+	}
+
+	// Añadir subtítulos externos junto al vídeo
+	if cfg.Sidecars {
+		sidecars, err := mkv.FindSidecars(input, extracted.NextTrackID())
+		if err != nil {
+			log.Warnf("Error buscando subtítulos externos: %v", err)
+		}
+		for _, s := range sidecars {
+			log.Infof("Añadiendo subtítulo externo <%s> (%s)", s.FilePath, s.Info.Properties.LanguageIETF.String())
+		}
+		extracted.AddTracks(sidecars...)
+	}
+
+	// Convertir los subtítulos de texto a UTF-8 antes de analizarlos, con la codificación configurada
+	// para los ficheros de 8 bits
+	for i := range extracted.Tracks {
+		t := &extracted.Tracks[i]
+		if !transcode.IsTextSubtitle(t) {
+			continue
+		}
+		encoding, targetFilePath, err := transcode.NormalizeEncoding(t, cfg.SubtitleEncoding)
+		if err != nil {
+			log.Warnf("Error al convertir la codificación de los subtítulos: %v. Se continua con la pista original.", err)
+		} else if targetFilePath != "" {
+			log.Infof("Subtítulos %s (%s) convertidos de %s a UTF-8", t.Info.Properties.CodecID, t.Info.Properties.LanguageIETF.String(), encoding)
+			fileReport.Add("encoding", "pista %d (%s): %s → UTF-8", t.Info.ID, t.Info.Properties.LanguageIETF.String(), encoding)
+			filesToDelete = append(filesToDelete, targetFilePath)
+		}
+	}
+
+	// Detectar el idioma de los subtítulos
+	if cfg.DetectLanguage != "" {
+		var preferred []mkv.LocaleInfo
+		for _, lang := range append([]string{cfg.MainLanguage, cfg.OriginalLanguage}, cfg.AudioLanguages...) {
+			if tag, err := mkv.FromIETFName(lang); err == nil {
+				preferred = append(preferred, tag)
+			}
+		}
+
+		for _, d := range analyze.DetectLanguages(extracted.Tracks, cfg.DetectLanguage, cfg.LanguageConfidence, preferred) {
+			description := fmt.Sprintf("pista %d: idioma detectado %s (confianza %.0f%%)", d.Track.Info.ID, d.Suggested.String(), d.Guess.Confidence*100)
+			if d.Contradicts {
+				description += fmt.Sprintf(", contradice la etiqueta %s", d.Previous.String())
+			}
+			if d.Applied {
+				description += fmt.Sprintf(", etiquetada como %s", d.Suggested.String())
+			}
+
+			if d.Contradicts && !d.Applied {
+				log.Warnf("Subtítulos con idioma incorrecto: %s", description)
+			} else {
+				log.Infof("Subtítulos: %s", description)
+			}
+			fileReport.Add("language", "%s", description)
+		}
+	}
+
+	// Detectar subtítulos forzados sin marcar
+	if cfg.DetectForced {
+		for _, f := range analyze.DetectForced(extracted.Tracks) {
+			reason := fmt.Sprintf("%d de %d líneas (%.0f%%), %s de %s con texto en pantalla (%.0f%%), repartidas en %s frente a %s (densidad del %.0f%%) respecto a la pista %d",
+				f.Stats.Events, f.Full.Events, f.EventRatio()*100,
+				f.Stats.Coverage.Round(time.Second), f.Full.Coverage.Round(time.Second), f.CoverageRatio()*100,
+				f.Stats.Span.Round(time.Second), f.Full.Span.Round(time.Second), f.DensityRatio()*100,
+				f.Reference.Info.ID)
+			log.Infof("Pista de subtítulos %d (%s) marcada como forzada: %s", f.Track.Info.ID, f.Track.Info.Properties.LanguageIETF.String(), reason)
+			fileReport.Add("forced", "pista %d (%s) marcada como forzada: %s", f.Track.Info.ID, f.Track.Info.Properties.LanguageIETF.String(), reason)
+		}
+	}
+
+	// Detectar subtítulos para sordos
+	if cfg.DetectSDH {
+		for _, sdh := range analyze.DetectSDH(extracted.Tracks) {
+			reason := fmt.Sprintf("nombre de pista \"%s\"", sdh.Name)
+			if sdh.Name == "" {
+				reason = fmt.Sprintf("%d de %d líneas con efectos de sonido, %d con nombres de personaje y %d con música",
+					sdh.Descriptions, sdh.Events, sdh.Speakers, sdh.Music)
+			}
+			log.Infof("Pista de subtítulos %d (%s) marcada para sordos: %s", sdh.Track.Info.ID, sdh.Track.Info.Properties.LanguageIETF.String(), reason)
+			fileReport.Add("sdh", "pista %d (%s) marcada para sordos: %s", sdh.Track.Info.ID, sdh.Track.Info.Properties.LanguageIETF.String(), reason)
+		}
+	}
+	if cfg.StripSDH {
+		for _, t := range sdhWithoutFullTrack(extracted.Tracks) {
+			stripped, err := transcode.StripHearingImpaired(&t, extracted.NextTrackID())
+			if err != nil {
+				log.Warnf("Error al generar subtítulos sin anotaciones para sordos: %v", err)
+				continue
+			}
+			log.Infof("Añadida copia sin anotaciones para sordos de la pista de subtítulos %d (%s)", t.Info.ID, t.Info.Properties.LanguageIETF.String())
+			fileReport.Add("sdh", "pista %d (%s): añadida copia sin anotaciones para sordos", t.Info.ID, t.Info.Properties.LanguageIETF.String())
+			filesToDelete = append(filesToDelete, stripped.FilePath)
+			extracted.AddTracks(stripped)
+		}
+	}
+
+	// Detectar pistas de comentarios
+	if cfg.DetectCommentary {
+		for _, c := range analyze.DetectCommentary(extracted.Tracks, cfg.CommentaryByChannels) {
+			reason := fmt.Sprintf("nombre de pista \"%s\"", c.Name)
+			if c.Reference != nil {
+				reason = fmt.Sprintf("%s frente a %s de la pista %d", c.Track.Info.ChannelLayout(), c.Reference.Info.ChannelLayout(), c.Reference.Info.ID)
+			}
+			log.Infof("Pista %s %d (%s) marcada como comentarios: %s", c.Track.Info.Type, c.Track.Info.ID, c.Track.Info.Properties.LanguageIETF.String(), reason)
+			fileReport.Add("commentary", "pista %d (%s) marcada como comentarios: %s", c.Track.Info.ID, c.Track.Info.Properties.LanguageIETF.String(), reason)
+		}
+		extracted.SortTracks()
+	}
+	if cfg.Commentary == analyze.PolicyDrop {
+		for _, t := range extracted.DropCommentary() {
+			log.Infof("Descartando pista de comentarios %d (%s)", t.Info.ID, t.Info.Properties.LanguageIETF.String())
+			fileReport.Add("commentary", "pista %d (%s) descartada por ser de comentarios", t.Info.ID, t.Info.Properties.LanguageIETF.String())
+		}
+	}
+
+	// Detectar pistas de audiodescripción
+	if cfg.DetectAudioDescription {
+		for _, t := range analyze.DetectAudioDescription(extracted.Tracks) {
+			log.Infof("Pista de audio %d (%s) marcada como audiodescripción: nombre de pista \"%s\"", t.Info.ID, t.Info.Properties.LanguageIETF.String(), t.Info.Properties.TrackName)
+			fileReport.Add("audio", "pista %d (%s) marcada como audiodescripción: nombre de pista \"%s\"", t.Info.ID, t.Info.Properties.LanguageIETF.String(), t.Info.Properties.TrackName)
+		}
+	}
+	if cfg.AudioDescription == analyze.PolicyDrop {
+		for _, t := range extracted.DropAudioDescription() {
+			log.Infof("Descartando pista de audiodescripción %d (%s)", t.Info.ID, t.Info.Properties.LanguageIETF.String())
+			fileReport.Add("audio", "pista %d (%s) descartada por ser audiodescripción", t.Info.ID, t.Info.Properties.LanguageIETF.String())
+		}
+	}
+
+	// Descartar pistas de audio con menos canales que otra del mismo idioma
+	if cfg.KeepHighestChannels {
+		for _, t := range extracted.DropLowerChannelAudio() {
+			log.Infof("Descartando pista de audio %d (%s, %s %s): hay otra con más canales", t.Info.ID, t.Info.Properties.LanguageIETF.String(), t.Info.CodecName(), t.Info.ChannelLayout())
+			fileReport.Add("audio", "pista %d (%s, %s %s) descartada por tener menos canales", t.Info.ID, t.Info.Properties.LanguageIETF.String(), t.Info.CodecName(), t.Info.ChannelLayout())
+		}
+	}
 
 	// Configuración de idiomas
-	originalLang, _ := mkv.FromIETFName("ja")
-	onlyAudios := []string{"ja", "es", "es-ES", "gl", "gl-ES"}
-	mainLang, _ := mkv.FromIETFName("es-ES")
+	originalLang, _ := mkv.FromIETFName(cfg.OriginalLanguage)
+	onlyAudios := cfg.AudioLanguages
+	mainLang, _ := mkv.FromIETFName(cfg.MainLanguage)
+	var fallbacks []mkv.LocaleInfo
+	for _, lang := range cfg.LanguageFallbacks {
+		if tag, err := mkv.FromIETFName(lang); err == nil {
+			fallbacks = append(fallbacks, tag)
+		} else {
+			log.Warnf("Idioma no válido en language_fallbacks: %s", lang)
+		}
+	}
+	strategy, err := mkv.NewDefaultTrackStrategy(cfg.DefaultTracks, mainLang, originalLang, fallbacks)
+	if err != nil {
+		return fmt.Errorf("error en la configuración de pistas por defecto: %v", err)
+	}
 
 	// Filtrar y modificar pistas
 	var selected []mkv.ExtractedTrack
-	var defaultTracks = extracted.GetDefaultTracks(mainLang)
+	var defaultTracks = extracted.GetDefaultTracks(strategy)
 	for _, t := range extracted.Tracks {
-		t.Info.Properties.TrackName = "" // Clear track name
-
 		if t.Info.Type == "video" || t.Info.Type == "audio" {
-			t.Info.Properties.TrackName = ""
+			if tags := t.Info.HDR().Tags(); t.Info.Type == "video" && len(tags) > 0 {
+				log.Infof("Pista de vídeo %d con %s", t.Info.ID, strings.Join(tags, ", "))
+			}
 			if t.Info.Properties.LanguageIETF == originalLang {
 				t.Info.Properties.FlagOriginal = true
 			}
@@ -117,35 +617,93 @@ func convertVideo(input string) error {
 		}
 	}
 
+	// Corregir la sincronización de pistas
+	for i := range selected {
+		t := &selected[i]
+		if rule := transcode.MatchRetimeRule(cfg.Retiming, filepath.Base(input), t); rule != nil {
+			if err := rule.Apply(t); err != nil {
+				log.Warnf("Regla de sincronización no válida: %v", err)
+				continue
+			}
+			factor := 1.0
+			if t.Operations.Stretch != 0 {
+				factor = t.Operations.Stretch
+			}
+			log.Infof("Ajustando sincronización de la pista %s %d (%s): retraso %d ms, factor %.6g", t.Info.Type, t.Info.ID, t.Info.Properties.LanguageIETF.String(), t.Operations.Delay, factor)
+			fileReport.Add("retime", "pista %d (%s): retraso %d ms, factor %.6g", t.Info.ID, t.Info.Properties.LanguageIETF.String(), t.Operations.Delay, factor)
+		}
+	}
+
 	// Convertir pistas con codecs no deseados
 	for i := range selected {
 		t := &selected[i]
 		if t.Info.Type == "audio" {
-			if strings.Index(t.Info.Properties.CodecID, "FLAC") != -1 {
-				log.Infof("Convirtiendo pista de audio FLAC a EAC3 (%s) desde <%s>...", t.Info.Properties.LanguageIETF.String(), t.FilePath)
-				targetFilePath := t.FilePath + ".eac3"
-				err := ffmpeg.Convert(ffmpeg.ConvertOptions{
-					Inputs: []ffmpeg.InputFile{{
-						Path: t.FilePath,
-					}},
-					OutputPath: targetFilePath,
-					Tracks: []ffmpeg.TrackConvertOptions{{
-						Index:   "a",
-						Encoder: ffmpeg.EncoderEAC3,
-					}},
-				})
+			if rule := transcode.MatchAudioRule(cfg.AudioRules(), t); rule != nil {
+				encoder, ok := caps.SelectEncoder(rule.Encoders)
+				if !ok {
+					log.Warnf("Ningún codificador disponible para convertir la pista de audio %s (%s). Se continua con la pista original.", t.Info.Properties.CodecID, t.Info.Properties.LanguageIETF.String())
+					continue
+				}
+
+				log.Infof("Convirtiendo pista de audio %s a %s (%s) desde <%s>...", t.Info.Properties.CodecID, encoder, t.Info.Properties.LanguageIETF.String(), t.FilePath)
+				targetFilePath, err := transcode.Audio(t, encoder, rule.Bitrate)
 				if err != nil {
 					log.Warnf("Error al convertir pista de audio: %v. Se continua con la pista original.", err)
 				} else {
-					t.FilePath = targetFilePath
 					filesToDelete = append(filesToDelete, targetFilePath)
-					t.Info.Codec = "A_EAC3"
-					t.Info.Properties.CodecID = "A_EAC3"
+				}
+			}
+		} else if t.Info.Type == "subtitles" {
+			if transcode.IsTextSubtitle(t) && t.Operations.Retimed() {
+				targetFilePath, err := transcode.Retime(t)
+				if err != nil {
+					log.Warnf("Error al ajustar la sincronización de los subtítulos: %v. Se ajustará con mkvmerge.", err)
+				} else {
+					filesToDelete = append(filesToDelete, targetFilePath)
+				}
+			}
+
+			if rule := transcode.MatchSubtitleRule(cfg.SubtitleConversion, t); rule != nil {
+				log.Infof("Convirtiendo subtítulos %s (%s) a %s desde <%s>...", t.Info.Properties.CodecID, t.Info.Properties.LanguageIETF.String(), rule.To, t.FilePath)
+				targetFilePath, err := transcode.Subtitle(t, rule.To, cfg.SubtitleStyle)
+				if err != nil {
+					log.Warnf("Error al convertir subtítulos: %v. Se continua con la pista original.", err)
+				} else {
+					filesToDelete = append(filesToDelete, targetFilePath)
+				}
+			}
+		} else if t.Info.Type == "video" {
+			if rule := transcode.MatchVideoRule(cfg.VideoTranscode, t, extracted.Duration); rule != nil && caps.HasEncoder(rule.Profile.Encoder) {
+				log.Infof("Recodificando pista de vídeo %s (%d kbps) con %s desde <%s>...", t.Info.Properties.CodecID, t.Bitrate(extracted.Duration)/1000, rule.Profile.Encoder, t.FilePath)
+				targetFilePath, err := transcode.Video(t, rule.Profile)
+				if err != nil {
+					log.Warnf("Error al recodificar pista de vídeo: %v. Se continua con la pista original.", err)
+				} else {
+					filesToDelete = append(filesToDelete, targetFilePath)
 				}
 			}
 		}
 	}
 
+	// Generar los nombres de las pistas
+	for i := range selected {
+		t := &selected[i]
+		name := cfg.TrackNames.Name(&t.Info, mainLang)
+		if name != t.Info.Properties.TrackName {
+			log.Debugf("Nombre de la pista %s %d: \"%s\" → \"%s\"", t.Info.Type, t.Info.ID, t.Info.Properties.TrackName, name)
+		}
+		t.Info.Properties.TrackName = name
+	}
+
+	// Comprobar las fuentes de los subtítulos ASS
+	attachments := extracted.Attachments
+	if cfg.CheckFonts {
+		attachments = collectFonts(selected, attachments, cfg.FontsDir, fileReport)
+	}
+	if cfg.SubsetFonts {
+		filesToDelete = append(filesToDelete, subsetFonts(selected, attachments, fileReport)...)
+	}
+
 	// Construir nombre de archivo de salida
 	parsedFileName := naming.Extract(filepath.Base(input))
 	for i := range selected {
@@ -176,14 +734,20 @@ func convertVideo(input string) error {
 	// Escribir fichero de salida
 	outputFile := path.Join(outputPath, parsedFileName.FileName())
 	log.Infof("Empaquetando fichero de salida %s ...", outputFile)
-	err = mkv.Merge(outputFile, mkv.ExtractedContainer{
-		Tracks:   selected,
-		Chapters: extracted.Chapters,
-	})
-	if err != nil {
-		log.Errorf("Error al reempaquetar: %v", err)
-	} else {
-		log.Info("Proceso completado!")
+	output := mkv.ExtractedContainer{
+		Tracks:      selected,
+		Attachments: attachments,
+		Chapters:    extracted.Chapters,
+		TrackOrder:  orderTracks(cfg.TrackOrder, selected, mainLang, originalLang),
+	}
+	if err := mkv.Merge(outputFile, output); err != nil {
+		return fmt.Errorf("error al reempaquetar: %v", err)
+	}
+	fileReport.Output = outputFile
+	log.Info("Proceso completado!")
+
+	if cfg.DeleteSidecars {
+		deleteSidecars(outputFile, output)
 	}
 
 	return nil
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"videorepack/analyze"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+	"videorepack/tools"
+	"videorepack/transcode"
+)
+
+// Config holds the repacking profile. Any field missing from the configuration file keeps its
+// default value.
+type Config struct {
+	MainLanguage     string   `json:"main_language"`
+	OriginalLanguage string   `json:"original_language"`
+	AudioLanguages   []string `json:"audio_languages"` // Audio tracks in other languages are dropped
+	// How the default tracks are chosen: "dub_first", "original_subs" (original audio with full
+	// subtitles), "original_only" or "language_chain"
+	DefaultTracks string `json:"default_tracks"`
+	// Main language fallbacks of the language_chain strategy, in order, e.g. ["es-ES", "es", "es-419"]
+	LanguageFallbacks []string `json:"language_fallbacks"`
+
+	// Fixes of the language and flags of the scanned tracks from their name, codec or language. Setting
+	// them replaces the built-in rules, an empty list disables them. Left nil by Default, see
+	// TrackPatchRules.
+	PatchRules []mkv.PatchRule `json:"patch_rules"`
+
+	// Add the subtitle files found next to the video, like "Episode 01.es.forced.srt"
+	Sidecars bool `json:"sidecars"`
+	// Delete the sidecar files once the output file is merged and verified
+	DeleteSidecars bool `json:"delete_sidecars"`
+
+	// Paths of the external tools, keyed by name (mkvmerge, mkvextract, ffmpeg). The
+	// VIDEOREPACK_<TOOL> environment variables take precedence.
+	Tools map[string]string `json:"tools"`
+	// Runs some tools inside a container image instead of the local installation
+	Container *tools.ContainerConfig `json:"container"`
+
+	// Order of the tracks of each type in the output file
+	TrackOrder mkv.TrackOrder `json:"track_order"`
+	// Templates of the track names, e.g. "{lang_native} {codec} {channels}{ - Forced}{ - SDH}"
+	TrackNames mkv.TrackNames `json:"track_names"`
+	// Keep only the audio tracks with the highest channel count of each language
+	KeepHighestChannels bool `json:"keep_highest_channels"`
+	// Audio conversion rules, the first matching rule is applied. Setting them replaces the
+	// built-in FLAC rule, an empty list disables audio conversion. Left nil by Default, see AudioRules.
+	AudioTranscode []transcode.AudioRule `json:"audio_transcode"`
+	// Guess the language of text subtitles from their content: "suggest" only reports it, "apply"
+	// tags the tracks without language and "override" also retags the tracks whose content
+	// contradicts their tag. Empty disables the detection. The trigram profiles are small, so the
+	// default only reports.
+	DetectLanguage string `json:"detect_language"`
+	// Minimum confidence, from 0 to 1, to apply a detected language or report a contradiction
+	LanguageConfidence float64 `json:"language_confidence"`
+	// Mark as forced the subtitle tracks that cover a small part of the dialogue of another track
+	// in the same language
+	DetectForced bool `json:"detect_forced"`
+	// Set the hearing impaired flag on SDH subtitles, detected from the track name or the content
+	DetectSDH bool `json:"detect_sdh"`
+	// Set the commentary flag on the tracks whose name marks them as commentary
+	DetectCommentary bool `json:"detect_commentary"`
+	// Also flag as commentary the mono or stereo audio tracks that follow a surround track in the
+	// same language. Unnamed stereo downmixes would be flagged too.
+	CommentaryByChannels bool `json:"commentary_by_channels"`
+	// What to do with commentary tracks: "keep" or "drop"
+	Commentary string `json:"commentary"`
+	// Set the visual impaired flag on the audio tracks whose name marks them as audio description
+	DetectAudioDescription bool `json:"detect_audio_description"`
+	// What to do with audio description tracks: "keep" or "drop"
+	AudioDescription string `json:"audio_description"`
+	// Add a copy without sound descriptions of the SDH text subtitles that have no other full track
+	// in the same language
+	StripSDH bool `json:"strip_sdh"`
+	// Charset assumed for 8-bit text subtitles that can't be told apart from their content, e.g.
+	// windows-1251 for a Cyrillic library. Western charsets are always detected.
+	SubtitleEncoding string `json:"subtitle_encoding"`
+	// Check that the fonts used by ASS subtitles are attached to the output
+	CheckFonts bool `json:"check_fonts"`
+	// Directory with font files to attach the fonts missing from the input file
+	FontsDir string `json:"fonts_dir"`
+	// Rewrite the attached fonts used by ASS subtitles with only the glyphs they draw
+	SubsetFonts bool `json:"subset_fonts"`
+	// Text subtitle conversion rules, the first matching rule is applied
+	SubtitleConversion []transcode.SubtitleRule `json:"subtitle_conversion"`
+	// Style of the lines of subtitles converted to ASS
+	SubtitleStyle subtitles.Style `json:"subtitle_style"`
+	// Timing fixes for the tracks of some shows or episodes, the first matching rule is applied
+	Retiming []transcode.RetimeRule `json:"retiming"`
+	// Video re-encoding rules, the first matching rule is applied. Empty disables video transcoding.
+	VideoTranscode []transcode.VideoRule `json:"video_transcode"`
+}
+
+func Default() *Config {
+	return &Config{
+		MainLanguage:           "es-ES",
+		OriginalLanguage:       "ja",
+		AudioLanguages:         []string{"ja", "es", "es-ES", "gl", "gl-ES"},
+		DefaultTracks:          mkv.StrategyDubFirst,
+		Sidecars:               true,
+		DetectLanguage:         analyze.LanguageSuggest,
+		LanguageConfidence:     0.5,
+		DetectForced:           true,
+		DetectSDH:              true,
+		DetectCommentary:       true,
+		Commentary:             analyze.PolicyKeep,
+		DetectAudioDescription: true,
+		AudioDescription:       analyze.PolicyKeep,
+		SubtitleEncoding:       "windows-1252",
+		CheckFonts:             true,
+		SubtitleStyle:          subtitles.DefaultStyle(),
+		TrackOrder: mkv.TrackOrder{
+			First:          mkv.OrderMainFirst,
+			ForcedFirst:    true,
+			SDHLast:        true,
+			CommentaryLast: true,
+		},
+		TrackNames: mkv.TrackNames{
+			Audio:     "{lang_native} {codec} {channels}{ - Commentary}{ - AD}",
+			Subtitles: "{lang_native}{ - Forced}{ - SDH}{ - Commentary}",
+			Language:  mkv.NamesMain,
+		},
+	}
+}
+
+// Load reads a JSON configuration file on top of the default configuration.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %v", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+// TrackPatchRules returns the configured patch rules, or the built-in ones when the configuration
+// doesn't set any. They aren't part of Default, as the rules of a configuration file would be decoded
+// on top of the built-in ones and keep the fields they don't set.
+func (c *Config) TrackPatchRules() []mkv.PatchRule {
+	if c.PatchRules == nil {
+		return mkv.DefaultPatchRules()
+	}
+	return c.PatchRules
+}
+
+// AudioRules returns the configured audio conversion rules, or the built-in one when the
+// configuration doesn't set any. An empty list disables audio conversion.
+func (c *Config) AudioRules() []transcode.AudioRule {
+	if c.AudioTranscode == nil {
+		return transcode.DefaultAudioRules()
+	}
+	return c.AudioTranscode
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPatchRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"patch_rules": [{"name": "mine", "track_name": "Foo"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := cfg.TrackPatchRules()
+	if len(rules) != 1 {
+		t.Fatalf("expected only the configured rule, got %d rules", len(rules))
+	}
+	if r := rules[0]; r.Name != "mine" || r.Set.Language != "" || r.Set.Forced != nil || len(r.Types) != 0 {
+		t.Errorf("configured rule merged with a built-in one: %+v", r)
+	}
+
+	if rules := Default().TrackPatchRules(); len(rules) == 0 {
+		t.Error("expected the built-in rules without configuration")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"patch_rules": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if rules := cfg.TrackPatchRules(); len(rules) != 0 {
+		t.Errorf("expected an empty list to disable the rules, got %d", len(rules))
+	}
+}
+
+func TestLoadAudioRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"audio_transcode": [{"codecs": ["A_DTS"]}, {"classes": ["lossless"], "encoders": ["flac"]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := cfg.AudioRules()
+	if len(rules) != 2 {
+		t.Fatalf("expected only the configured rules, got %d rules", len(rules))
+	}
+	if r := rules[0]; len(r.Encoders) != 0 {
+		t.Errorf("configured rule merged with the built-in one: %+v", r)
+	}
+	if r := rules[1]; len(r.Codecs) != 0 {
+		t.Errorf("configured rule merged with the built-in one: %+v", r)
+	}
+
+	if rules := Default().AudioRules(); len(rules) != 1 || rules[0].Codecs[0] != "A_FLAC" {
+		t.Errorf("expected the built-in rule without configuration, got %+v", rules)
+	}
+}
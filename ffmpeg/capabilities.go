@@ -0,0 +1,138 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"videorepack/tools"
+)
+
+type Encoder struct {
+	Name         string
+	Type         StreamType
+	Description  string
+	Experimental bool
+}
+
+type Codec struct {
+	Name        string
+	Type        StreamType
+	Description string
+	Decoding    bool
+	Encoding    bool
+}
+
+// Capabilities lists the encoders and codecs supported by the local ffmpeg build.
+type Capabilities struct {
+	Encoders map[string]Encoder
+	Codecs   map[string]Codec
+}
+
+// DetectCapabilities queries ffmpeg for its encoders and codecs.
+func DetectCapabilities() (*Capabilities, error) {
+	encodersOut, err := tools.Query(tools.FFmpeg, "-hide_banner", "-encoders")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg -encoders error: %v", err)
+	}
+
+	codecsOut, err := tools.Query(tools.FFmpeg, "-hide_banner", "-codecs")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg -codecs error: %v", err)
+	}
+
+	return &Capabilities{
+		Encoders: ParseEncoders(string(encodersOut)),
+		Codecs:   ParseCodecs(string(codecsOut)),
+	}, nil
+}
+
+func (c *Capabilities) HasEncoder(name string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.Encoders[name]
+	return ok
+}
+
+func (c *Capabilities) CanDecode(codec string) bool {
+	if c == nil {
+		return false
+	}
+	return c.Codecs[codec].Decoding
+}
+
+// SelectEncoder returns the first encoder of the fallback chain available in this ffmpeg build.
+// Experimental encoders are skipped, as ffmpeg refuses to run them without -strict experimental.
+func (c *Capabilities) SelectEncoder(chain []string) (string, bool) {
+	for _, name := range chain {
+		if c.HasEncoder(name) && !c.Encoders[name].Experimental {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ParseEncoders parses the output of ffmpeg -encoders.
+func ParseEncoders(out string) map[string]Encoder {
+	encoders := make(map[string]Encoder)
+	forEachEntry(out, func(flags string, name string, description string) {
+		encoders[name] = Encoder{
+			Name:         name,
+			Type:         flagStreamType(flags[0]),
+			Description:  description,
+			Experimental: len(flags) > 3 && flags[3] == 'X',
+		}
+	})
+	return encoders
+}
+
+// ParseCodecs parses the output of ffmpeg -codecs.
+func ParseCodecs(out string) map[string]Codec {
+	codecs := make(map[string]Codec)
+	forEachEntry(out, func(flags string, name string, description string) {
+		if len(flags) < 3 {
+			return
+		}
+		codecs[name] = Codec{
+			Name:        name,
+			Type:        flagStreamType(flags[2]),
+			Description: description,
+			Decoding:    flags[0] == 'D',
+			Encoding:    flags[1] == 'E',
+		}
+	})
+	return codecs
+}
+
+// forEachEntry walks the entries listed after the legend of an ffmpeg listing. Every entry line is
+// made of a flags column, the name and a free description.
+func forEachEntry(out string, fn func(flags string, name string, description string)) {
+	inEntries := false
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !inEntries {
+			inEntries = strings.HasPrefix(line, "---")
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		description := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[len(fields[0]):]), fields[1]))
+		fn(fields[0], fields[1], description)
+	}
+}
+
+func flagStreamType(flag byte) StreamType {
+	switch flag {
+	case 'V':
+		return StreamVideo
+	case 'A':
+		return StreamAudio
+	case 'S':
+		return StreamSubtitle
+	}
+	return StreamAny
+}
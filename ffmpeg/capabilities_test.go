@@ -0,0 +1,83 @@
+package ffmpeg
+
+import "testing"
+
+const encodersOutput = `Encoders:
+ V..... = Video
+ A..... = Audio
+ S..... = Subtitle
+ .F.... = Frame-level multithreading
+ ..S... = Slice-level multithreading
+ ...X.. = Codec is experimental
+ ....B. = Supports draw_horiz_band
+ .....D = Supports direct rendering method 1
+ ------
+ V....D libx265              libx265 H.265 / HEVC (codec hevc)
+ A....D ac3                  ATSC A/52A (AC-3)
+ A..X.D truehd               TrueHD
+ S..... srt                  SubRip subtitle
+`
+
+const codecsOutput = `Codecs:
+ D..... = Decoding supported
+ .E.... = Encoding supported
+ ..V... = Video codec
+ ..A... = Audio codec
+ ..S... = Subtitle codec
+ ..D... = Data codec
+ ..T... = Attachment codec
+ ...I.. = Intra frame-only codec
+ ....L. = Lossy compression
+ .....S = Lossless compression
+ -------
+ D.V.L. vc1                  SMPTE VC-1
+ DEA.L. eac3                 ATSC A/52B (AC-3, E-AC-3)
+`
+
+func TestParseEncoders(t *testing.T) {
+	encoders := ParseEncoders(encodersOutput)
+	if len(encoders) != 4 {
+		t.Fatalf("expected 4 encoders, got %d: %v", len(encoders), encoders)
+	}
+
+	x265 := encoders["libx265"]
+	if x265.Type != StreamVideo || x265.Description != "libx265 H.265 / HEVC (codec hevc)" || x265.Experimental {
+		t.Errorf("unexpected libx265 entry: %+v", x265)
+	}
+	if !encoders["truehd"].Experimental || encoders["truehd"].Type != StreamAudio {
+		t.Errorf("unexpected truehd entry: %+v", encoders["truehd"])
+	}
+	if encoders["srt"].Type != StreamSubtitle {
+		t.Errorf("unexpected srt entry: %+v", encoders["srt"])
+	}
+}
+
+func TestParseCodecs(t *testing.T) {
+	codecs := ParseCodecs(codecsOutput)
+
+	if vc1 := codecs["vc1"]; !vc1.Decoding || vc1.Encoding || vc1.Type != StreamVideo {
+		t.Errorf("unexpected vc1 entry: %+v", vc1)
+	}
+	if eac3 := codecs["eac3"]; !eac3.Decoding || !eac3.Encoding || eac3.Type != StreamAudio {
+		t.Errorf("unexpected eac3 entry: %+v", eac3)
+	}
+}
+
+func TestSelectEncoder(t *testing.T) {
+	caps := &Capabilities{Encoders: ParseEncoders(encodersOutput)}
+
+	if encoder, ok := caps.SelectEncoder([]string{EncoderEAC3, EncoderAC3, EncoderAAC}); !ok || encoder != EncoderAC3 {
+		t.Errorf("expected fallback to ac3, got %q (%v)", encoder, ok)
+	}
+	if _, ok := caps.SelectEncoder([]string{EncoderEAC3}); ok {
+		t.Error("expected no available encoder")
+	}
+	if encoder, ok := caps.SelectEncoder([]string{"truehd", EncoderAC3}); !ok || encoder != EncoderAC3 {
+		t.Errorf("expected the experimental encoder to be skipped, got %q (%v)", encoder, ok)
+	}
+
+	var missing *Capabilities
+	if missing.HasEncoder(EncoderX265) {
+		t.Error("nil capabilities must not report encoders")
+	}
+}
@@ -0,0 +1,267 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type StreamType string
+
+const (
+	StreamAny      StreamType = ""
+	StreamVideo    StreamType = "v"
+	StreamAudio    StreamType = "a"
+	StreamSubtitle StreamType = "s"
+)
+
+type Disposition string
+
+const (
+	DispositionDefault         Disposition = "default"
+	DispositionForced          Disposition = "forced"
+	DispositionOriginal        Disposition = "original"
+	DispositionComment         Disposition = "comment"
+	DispositionHearingImpaired Disposition = "hearing_impaired"
+	DispositionVisualImpaired  Disposition = "visual_impaired"
+)
+
+// StreamSpecifier builds a stream specifier such as "a:1". A negative index selects every stream of the type.
+func StreamSpecifier(t StreamType, index int) string {
+	spec := string(t)
+	if index >= 0 {
+		if spec != "" {
+			spec += ":"
+		}
+		spec += strconv.Itoa(index)
+	}
+	return spec
+}
+
+type Input struct {
+	index   int
+	Path    string
+	Format  string        // Forces the input format (-f)
+	Seek    time.Duration // Start position (-ss)
+	Length  time.Duration // Amount of input read (-t)
+	Options []string      // Extra input options, placed before -i
+}
+
+// Index returns the position of the input in the command, as used in -map.
+func (in *Input) Index() int {
+	return in.index
+}
+
+// Stream returns the map specifier of a stream of this input, e.g. "0:a:1".
+func (in *Input) Stream(t StreamType, index int) string {
+	spec := StreamSpecifier(t, index)
+	if spec == "" {
+		return strconv.Itoa(in.index)
+	}
+	return fmt.Sprintf("%d:%s", in.index, spec)
+}
+
+func (in *Input) args() []string {
+	var args []string
+	if in.Format != "" {
+		args = append(args, "-f", in.Format)
+	}
+	if in.Seek > 0 {
+		args = append(args, "-ss", formatDuration(in.Seek))
+	}
+	if in.Length > 0 {
+		args = append(args, "-t", formatDuration(in.Length))
+	}
+	args = append(args, in.Options...)
+	return append(args, "-i", in.Path)
+}
+
+type option struct {
+	name  string
+	value string
+}
+
+// OutputStream holds the options of the output streams selected by a stream specifier.
+type OutputStream struct {
+	spec           string
+	codec          string
+	options        []option
+	metadata       []option
+	disposition    []Disposition
+	hasDisposition bool
+}
+
+func (s *OutputStream) Codec(encoder string) *OutputStream {
+	s.codec = encoder
+	return s
+}
+
+// Bitrate sets the target bitrate, in ffmpeg notation (e.g. "640k").
+func (s *OutputStream) Bitrate(bitrate string) *OutputStream {
+	return s.Option("b", bitrate)
+}
+
+// Option sets a per-stream option, given without the leading dash (e.g. "crf", "pix_fmt").
+func (s *OutputStream) Option(name string, value string) *OutputStream {
+	s.options = append(s.options, option{name, value})
+	return s
+}
+
+func (s *OutputStream) Metadata(key string, value string) *OutputStream {
+	s.metadata = append(s.metadata, option{key, value})
+	return s
+}
+
+// Disposition replaces the stream disposition. Without arguments every flag is cleared.
+func (s *OutputStream) Disposition(flags ...Disposition) *OutputStream {
+	s.disposition = flags
+	s.hasDisposition = true
+	return s
+}
+
+func (s *OutputStream) args() []string {
+	suffix := ""
+	if s.spec != "" {
+		suffix = ":" + s.spec
+	}
+
+	var args []string
+	if s.codec != "" {
+		args = append(args, "-c"+suffix, s.codec)
+	}
+	for _, opt := range s.options {
+		args = append(args, "-"+opt.name+suffix, opt.value)
+	}
+	for _, meta := range s.metadata {
+		args = append(args, "-metadata:s"+suffix, meta.name+"="+meta.value)
+	}
+	if s.hasDisposition {
+		value := "0"
+		if len(s.disposition) > 0 {
+			flags := make([]string, len(s.disposition))
+			for i, d := range s.disposition {
+				flags[i] = string(d)
+			}
+			value = strings.Join(flags, "+")
+		}
+		args = append(args, "-disposition"+suffix, value)
+	}
+	return args
+}
+
+// Command is a typed builder of a single-output ffmpeg invocation.
+type Command struct {
+	Globals      []string
+	inputs       []*Input
+	filterGraph  *FilterGraph
+	maps         []string
+	streams      []*OutputStream
+	metadata     []option
+	outputFormat string
+	outputOpts   []string
+	output       string
+}
+
+// NewCommand creates a command with the default global options: no banner and machine readable progress.
+func NewCommand(output string) *Command {
+	return &Command{
+		Globals: []string{"-nostats", "-hide_banner", "-progress", "-"},
+		output:  output,
+	}
+}
+
+// Input adds an input file. The returned Input can be tuned before the arguments are generated.
+func (c *Command) Input(path string) *Input {
+	in := &Input{index: len(c.inputs), Path: path}
+	c.inputs = append(c.inputs, in)
+	return in
+}
+
+func (c *Command) Inputs() []*Input {
+	return c.inputs
+}
+
+// Map selects an input stream (e.g. "0:a:0") or a filter graph output label (e.g. "[out]").
+func (c *Command) Map(spec string) *Command {
+	c.maps = append(c.maps, spec)
+	return c
+}
+
+// FilterComplex sets the filter graph of the command (-filter_complex).
+func (c *Command) FilterComplex(graph *FilterGraph) *Command {
+	c.filterGraph = graph
+	return c
+}
+
+// Stream returns the options of the output streams matching the specifier, creating them if needed.
+func (c *Command) Stream(t StreamType, index int) *OutputStream {
+	spec := StreamSpecifier(t, index)
+	for _, s := range c.streams {
+		if s.spec == spec {
+			return s
+		}
+	}
+	s := &OutputStream{spec: spec}
+	c.streams = append(c.streams, s)
+	return s
+}
+
+// Metadata sets a global metadata entry of the output file.
+func (c *Command) Metadata(key string, value string) *Command {
+	c.metadata = append(c.metadata, option{key, value})
+	return c
+}
+
+// Format forces the output format (-f).
+func (c *Command) Format(format string) *Command {
+	c.outputFormat = format
+	return c
+}
+
+// OutputOption adds a raw output option, given without the leading dash.
+func (c *Command) OutputOption(name string, value string) *Command {
+	c.outputOpts = append(c.outputOpts, "-"+name, value)
+	return c
+}
+
+func (c *Command) Output() string {
+	return c.output
+}
+
+// Args generates the ffmpeg argument vector, without the program name.
+func (c *Command) Args() []string {
+	args := append([]string{}, c.Globals...)
+
+	for _, in := range c.inputs {
+		args = append(args, in.args()...)
+	}
+
+	if c.filterGraph != nil && !c.filterGraph.Empty() {
+		args = append(args, "-filter_complex", c.filterGraph.String())
+	}
+
+	for _, m := range c.maps {
+		args = append(args, "-map", m)
+	}
+
+	for _, s := range c.streams {
+		args = append(args, s.args()...)
+	}
+
+	for _, meta := range c.metadata {
+		args = append(args, "-metadata", meta.name+"="+meta.value)
+	}
+
+	if c.outputFormat != "" {
+		args = append(args, "-f", c.outputFormat)
+	}
+	args = append(args, c.outputOpts...)
+
+	return append(args, c.output)
+}
+
+// formatDuration writes a duration in seconds, the most portable of the ffmpeg time notations.
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
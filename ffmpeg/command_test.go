@@ -0,0 +1,108 @@
+package ffmpeg
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func assertArgs(t *testing.T, got []string, want []string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected args\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestCommandInputs(t *testing.T) {
+	cmd := NewCommand("out.mkv")
+	cmd.Globals = nil
+
+	main := cmd.Input("main.mkv")
+	main.Seek = 90 * time.Second
+	main.Length = 1500 * time.Millisecond
+	extra := cmd.Input("tone")
+	extra.Format = "lavfi"
+	extra.Options = []string{"-re"}
+
+	cmd.Map(main.Stream(StreamVideo, 0)).Map(extra.Stream(StreamAny, -1))
+
+	assertArgs(t, cmd.Args(), []string{
+		"-ss", "90", "-t", "1.5", "-i", "main.mkv",
+		"-f", "lavfi", "-re", "-i", "tone",
+		"-map", "0:v:0", "-map", "1",
+		"out.mkv",
+	})
+}
+
+func TestCommandStreams(t *testing.T) {
+	cmd := NewCommand("out.mka")
+	in := cmd.Input("in.flac")
+	cmd.Map(in.Stream(StreamAudio, -1))
+
+	cmd.Stream(StreamAudio, 0).Codec(EncoderEAC3).Bitrate("640k").
+		Metadata("language", "spa").Disposition(DispositionDefault, DispositionOriginal)
+	cmd.Stream(StreamAudio, 1).Codec(EncoderCopy).Disposition()
+	// Stream returns the existing options for the same specifier
+	cmd.Stream(StreamAudio, 0).Option("ac", "6")
+	cmd.Metadata("title", "Episode 1").Format("matroska")
+
+	assertArgs(t, cmd.Args(), []string{
+		"-nostats", "-hide_banner", "-progress", "-",
+		"-i", "in.flac",
+		"-map", "0:a",
+		"-c:a:0", "eac3", "-b:a:0", "640k", "-ac:a:0", "6",
+		"-metadata:s:a:0", "language=spa", "-disposition:a:0", "default+original",
+		"-c:a:1", "copy", "-disposition:a:1", "0",
+		"-metadata", "title=Episode 1",
+		"-f", "matroska",
+		"out.mka",
+	})
+}
+
+func TestCommandFilterComplex(t *testing.T) {
+	cmd := NewCommand("out.mkv")
+	cmd.Globals = nil
+	in := cmd.Input("in.mkv")
+
+	graph := NewFilterGraph().
+		Chain([]string{in.Stream(StreamVideo, 0)}, []Filter{
+			NewFilter("scale").With("w", "1280").With("h", "-2"),
+			NewFilter("format", "yuv420p10le"),
+		}, []string{"v"}).
+		Chain([]string{in.Stream(StreamAudio, 0)}, []Filter{NewFilter("pan", "stereo|c0=FL|c1=FR")}, []string{"a"})
+	cmd.FilterComplex(graph).Map("[v]").Map("[a]")
+	cmd.Stream(StreamVideo, -1).Codec(EncoderX265).Option("crf", "20")
+
+	assertArgs(t, cmd.Args(), []string{
+		"-i", "in.mkv",
+		"-filter_complex", `[0:v:0]scale=w=1280:h=-2,format=yuv420p10le[v];[0:a:0]pan=stereo|c0\\=FL|c1\\=FR[a]`,
+		"-map", "[v]", "-map", "[a]",
+		"-c:v", "libx265", "-crf:v", "20",
+		"out.mkv",
+	})
+}
+
+func TestConvertOptionsCommand(t *testing.T) {
+	opts := ConvertOptions{
+		Inputs:     []InputFile{{Path: "track_1.hevc"}},
+		OutputPath: "track_1.hevc.hevc",
+		Tracks: []TrackConvertOptions{{
+			Index:         "v",
+			Encoder:       EncoderX265,
+			CRF:           18,
+			Preset:        "slow",
+			PixelFormat:   "yuv420p10le",
+			EncoderParams: "repeat-headers=1",
+		}},
+		PassthroughFrames: true,
+	}
+
+	assertArgs(t, opts.Command().Args(), []string{
+		"-nostats", "-hide_banner", "-progress", "-",
+		"-i", "track_1.hevc",
+		"-c:v", "libx265", "-crf:v", "18", "-preset:v", "slow", "-pix_fmt:v", "yuv420p10le",
+		"-x265-params:v", "repeat-headers=1",
+		"-fps_mode", "passthrough",
+		"track_1.hevc.hevc",
+	})
+}
@@ -4,41 +4,78 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"strconv"
+	"videorepack/tools"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func Convert(opts ConvertOptions) error {
-	// Composite ffmpeg command based on options
-	args := []string{"-nostats", "-hide_banner", "-progress", "-"}
+// Command builds the ffmpeg command equivalent to the conversion options.
+func (opts *ConvertOptions) Command() *Command {
+	cmd := NewCommand(opts.OutputPath)
 
 	for _, input := range opts.Inputs {
-		// Check if file exists and we can read it
-		if inf, err := os.Stat(input.Path); os.IsNotExist(err) {
-			return err
-		} else if inf.Mode().IsRegular() && inf.Mode().Perm()&(1<<(uint(7))) == 0 {
-			return errors.New("cannot read input file: " + input.Path)
-		}
-		args = append(args, "-i", fmt.Sprintf("%s", input.Path))
+		cmd.Input(input.Path)
 		if input.TrackMap != "" {
-			args = append(args, "-map", input.TrackMap)
+			cmd.Map(input.TrackMap)
 		}
 	}
 
 	// Add track conversion options
 	for _, track := range opts.Tracks {
-		args = append(args, "-c:"+track.Index, track.Encoder)
+		stream := &OutputStream{spec: track.Index}
+		stream.Codec(track.Encoder)
+		if track.CRF > 0 {
+			stream.Option("crf", strconv.Itoa(track.CRF))
+		}
+		if track.Preset != "" {
+			stream.Option("preset", track.Preset)
+		}
+		if track.PixelFormat != "" {
+			stream.Option("pix_fmt", track.PixelFormat)
+		}
+		if track.EncoderParams != "" {
+			switch track.Encoder {
+			case EncoderX265:
+				stream.Option("x265-params", track.EncoderParams)
+			case EncoderSVTAV1:
+				stream.Option("svtav1-params", track.EncoderParams)
+			}
+		}
+		cmd.streams = append(cmd.streams, stream)
 	}
 
-	// Set output path
-	args = append(args, fmt.Sprintf("%s", opts.OutputPath))
+	if opts.PassthroughFrames {
+		cmd.OutputOption("fps_mode", "passthrough")
+	}
+
+	return cmd
+}
+
+func Convert(opts ConvertOptions) error {
+	return Run(opts.Command())
+}
+
+// Run checks the inputs of the command and executes it.
+func Run(cmd *Command) error {
+	for _, input := range cmd.Inputs() {
+		if input.Format == "lavfi" {
+			continue
+		}
+
+		// Check if file exists and we can read it
+		if inf, err := os.Stat(input.Path); os.IsNotExist(err) {
+			return err
+		} else if inf.Mode().IsRegular() && inf.Mode().Perm()&(1<<(uint(7))) == 0 {
+			return errors.New("cannot read input file: " + input.Path)
+		}
+	}
 
 	// Execute ffmpeg command
+	args := cmd.Args()
 	log.WithFields(log.Fields{"process": "ffmpeg"}).Tracef("Executing ffmpeg with args: %v", args)
-	cmd := exec.Command("ffmpeg", args...)
-	if _, err := cmd.CombinedOutput(); err != nil {
-		var exitErr *exec.ExitError
+	if _, err := tools.Run(tools.FFmpeg, args...); err != nil {
+		var exitErr *tools.ExitError
 		if errors.As(err, &exitErr) {
 			return fmt.Errorf("ffmpeg execution error: %v", exitErr)
 		} else {
@@ -46,6 +83,5 @@ func Convert(opts ConvertOptions) error {
 		}
 	}
 
-	// Return the converted track info
 	return nil
 }
@@ -1,6 +1,12 @@
 package ffmpeg
 
 const (
-	EncoderCopy = "copy"
-	EncoderEAC3 = "eac3"
+	EncoderCopy   = "copy"
+	EncoderEAC3   = "eac3"
+	EncoderAC3    = "ac3"
+	EncoderAAC    = "aac"
+	EncoderOpus   = "libopus"
+	EncoderFLAC   = "flac"
+	EncoderX265   = "libx265"
+	EncoderSVTAV1 = "libsvtav1"
 )
@@ -0,0 +1,117 @@
+package ffmpeg
+
+import (
+	"strings"
+)
+
+// Filter is a node of a filter graph, such as scale=w=1280:h=-2. Positional arguments are written
+// before the named options.
+type Filter struct {
+	Name    string
+	Args    []string
+	Options []FilterOption
+}
+
+type FilterOption struct {
+	Name  string
+	Value string
+}
+
+func NewFilter(name string, args ...string) Filter {
+	return Filter{Name: name, Args: args}
+}
+
+// With adds a named option to a copy of the filter.
+func (f Filter) With(name string, value string) Filter {
+	f.Options = append(append([]FilterOption{}, f.Options...), FilterOption{name, value})
+	return f
+}
+
+func (f Filter) String() string {
+	var params []string
+	for _, arg := range f.Args {
+		params = append(params, escapeFilterValue(arg))
+	}
+	for _, opt := range f.Options {
+		params = append(params, opt.Name+"="+escapeFilterValue(opt.Value))
+	}
+
+	if len(params) == 0 {
+		return f.Name
+	}
+	return f.Name + "=" + strings.Join(params, ":")
+}
+
+// FilterChain is a linear sequence of filters, with labelled input and output pads.
+type FilterChain struct {
+	Inputs  []string // Input pads, either stream specifiers ("0:a") or labels of other chains
+	Filters []Filter
+	Outputs []string
+}
+
+func (fc FilterChain) String() string {
+	var sb strings.Builder
+	for _, in := range fc.Inputs {
+		sb.WriteString("[" + in + "]")
+	}
+
+	filters := make([]string, len(fc.Filters))
+	for i, f := range fc.Filters {
+		filters[i] = f.String()
+	}
+	sb.WriteString(strings.Join(filters, ","))
+
+	for _, out := range fc.Outputs {
+		sb.WriteString("[" + out + "]")
+	}
+	return sb.String()
+}
+
+// FilterGraph is a -filter_complex graph composed of filter chains.
+type FilterGraph struct {
+	Chains []FilterChain
+}
+
+func NewFilterGraph() *FilterGraph {
+	return &FilterGraph{}
+}
+
+// Chain appends a chain of filters reading from the inputs and writing to the outputs labels.
+func (g *FilterGraph) Chain(inputs []string, filters []Filter, outputs []string) *FilterGraph {
+	g.Chains = append(g.Chains, FilterChain{Inputs: inputs, Filters: filters, Outputs: outputs})
+	return g
+}
+
+func (g *FilterGraph) Empty() bool {
+	return len(g.Chains) == 0
+}
+
+func (g *FilterGraph) String() string {
+	chains := make([]string, len(g.Chains))
+	for i, c := range g.Chains {
+		chains[i] = c.String()
+	}
+	return strings.Join(chains, ";")
+}
+
+// escapeFilterValue escapes a filter argument for both levels of the filter graph syntax: first
+// for the filter options parser and then for the graph parser. See
+// https://ffmpeg.org/ffmpeg-filters.html#Notes-on-filtergraph-escaping
+func escapeFilterValue(value string) string {
+	return backslashEscape(backslashEscape(value, `\':=`), `\'[],;`)
+}
+
+func backslashEscape(value string, special string) string {
+	if !strings.ContainsAny(value, special) {
+		return value
+	}
+
+	var sb strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(special, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
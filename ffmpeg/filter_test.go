@@ -0,0 +1,45 @@
+package ffmpeg
+
+import "testing"
+
+func TestFilterString(t *testing.T) {
+	tests := []struct {
+		filter Filter
+		want   string
+	}{
+		{NewFilter("anull"), "anull"},
+		{NewFilter("volume", "0.5"), "volume=0.5"},
+		{NewFilter("scale", "1280", "-2").With("flags", "lanczos"), "scale=1280:-2:flags=lanczos"},
+		{NewFilter("drawtext").With("text", "it's 1:0, [live]"), `drawtext=text=it\\\'s 1\\:0\, \[live\]`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.String(); got != tt.want {
+			t.Errorf("Filter.String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestFilterWithDoesNotAlias(t *testing.T) {
+	base := NewFilter("scale").With("w", "1280")
+	a := base.With("h", "720")
+	b := base.With("h", "-2")
+
+	if a.String() != "scale=w=1280:h=720" || b.String() != "scale=w=1280:h=-2" {
+		t.Errorf("filters share options: %q, %q", a.String(), b.String())
+	}
+}
+
+func TestFilterGraphString(t *testing.T) {
+	graph := NewFilterGraph()
+	if !graph.Empty() {
+		t.Fatal("new graph is not empty")
+	}
+
+	graph.Chain([]string{"0:a", "1:a"}, []Filter{NewFilter("amix").With("inputs", "2")}, []string{"mix"}).
+		Chain([]string{"mix"}, []Filter{NewFilter("loudnorm")}, []string{"out"})
+
+	if got, want := graph.String(), "[0:a][1:a]amix=inputs=2[mix];[mix]loudnorm[out]"; got != want {
+		t.Errorf("FilterGraph.String() = %q, want %q", got, want)
+	}
+}
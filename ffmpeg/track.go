@@ -3,6 +3,12 @@ package ffmpeg
 type TrackConvertOptions struct {
 	Index   string
 	Encoder string
+	// Constant rate factor for video encoders. Zero keeps the encoder default.
+	CRF         int
+	Preset      string
+	PixelFormat string
+	// Encoder private parameters, passed as -x265-params or -svtav1-params
+	EncoderParams string
 }
 
 type InputFile struct {
@@ -15,4 +21,7 @@ type ConvertOptions struct {
 	Inputs     []InputFile
 	OutputPath string
 	Tracks     []TrackConvertOptions
+	// Encode every decoded frame as is, without dropping or duplicating frames, so an external
+	// timestamps file still matches the output
+	PassthroughFrames bool
 }
@@ -0,0 +1,121 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// parseCmap returns the glyph of every Unicode character mapped by the font. The segment mappings
+// of the BMP (format 4) are read first and the full Unicode ones (format 12) override them.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errTruncated
+	}
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	if len(cmap) < 4+numTables*8 {
+		return nil, errTruncated
+	}
+
+	var bmp, full [][]byte
+	for i := 0; i < numTables; i++ {
+		record := cmap[4+i*8:]
+		platform := binary.BigEndian.Uint16(record)
+		encoding := binary.BigEndian.Uint16(record[2:])
+		offset := binary.BigEndian.Uint32(record[4:])
+		if platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10)) {
+			continue
+		}
+		if uint64(offset)+2 > uint64(len(cmap)) {
+			return nil, errTruncated
+		}
+
+		subtable := cmap[offset:]
+		switch binary.BigEndian.Uint16(subtable) {
+		case 4:
+			bmp = append(bmp, subtable)
+		case 12:
+			full = append(full, subtable)
+		}
+	}
+	if len(bmp) == 0 && len(full) == 0 {
+		return nil, fmt.Errorf("font has no Unicode character map")
+	}
+
+	glyphs := make(map[rune]uint16)
+	for _, subtable := range bmp {
+		if err := parseCmap4(subtable, glyphs); err != nil {
+			return nil, err
+		}
+	}
+	for _, subtable := range full {
+		if err := parseCmap12(subtable, glyphs); err != nil {
+			return nil, err
+		}
+	}
+	return glyphs, nil
+}
+
+func parseCmap4(data []byte, glyphs map[rune]uint16) error {
+	if len(data) < 14 {
+		return errTruncated
+	}
+	segCount := int(binary.BigEndian.Uint16(data[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if len(data) < idRangeOffsets+segCount*2 {
+		return errTruncated
+	}
+
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(data[endCodes+i*2:]))
+		start := int(binary.BigEndian.Uint16(data[startCodes+i*2:]))
+		delta := binary.BigEndian.Uint16(data[idDeltas+i*2:])
+		rangeOffset := int(binary.BigEndian.Uint16(data[idRangeOffsets+i*2:]))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var g uint16
+			if rangeOffset == 0 {
+				g = uint16(c) + delta
+			} else {
+				// The offset is relative to its own position in the idRangeOffset array
+				pos := idRangeOffsets + i*2 + rangeOffset + (c-start)*2
+				if pos+2 > len(data) {
+					return errTruncated
+				}
+				if g = binary.BigEndian.Uint16(data[pos:]); g != 0 {
+					g += delta
+				}
+			}
+			if g != 0 {
+				glyphs[rune(c)] = g
+			}
+		}
+	}
+	return nil
+}
+
+func parseCmap12(data []byte, glyphs map[rune]uint16) error {
+	if len(data) < 16 {
+		return errTruncated
+	}
+	numGroups := int(binary.BigEndian.Uint32(data[12:]))
+	if len(data) < 16+numGroups*12 {
+		return errTruncated
+	}
+
+	for i := 0; i < numGroups; i++ {
+		group := data[16+i*12:]
+		start := binary.BigEndian.Uint32(group)
+		end := binary.BigEndian.Uint32(group[4:])
+		glyph := binary.BigEndian.Uint32(group[8:])
+		if end > 0x10FFFF || end < start {
+			return fmt.Errorf("invalid character map group")
+		}
+		for c := start; c <= end; c++ {
+			glyphs[rune(c)] = uint16(glyph + c - start)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package fonts
+
+import "encoding/binary"
+
+// GSUB lookup types that replace glyphs by others
+const (
+	gsubSingle    = 1
+	gsubMultiple  = 2
+	gsubAlternate = 3
+	gsubLigature  = 4
+	gsubExtension = 7
+)
+
+type gsubSubtable struct {
+	lookupType uint16
+	offset     int
+	coverage   []uint16
+}
+
+// gsubClosure adds the glyphs the kept glyphs can be substituted by. Every substitution lookup is
+// followed regardless of the features and contexts that enable it, which may keep a few glyphs
+// more than needed. Malformed tables are ignored.
+func gsubClosure(gsub []byte, keep map[uint16]bool) {
+	r := reader(gsub)
+	lookupList := int(r.u16(8))
+	if lookupList == 0 {
+		return
+	}
+
+	var subtables []gsubSubtable
+	count := int(r.u16(lookupList))
+	for i := 0; i < count; i++ {
+		lookup := lookupList + int(r.u16(lookupList+2+i*2))
+		lookupType := r.u16(lookup)
+		subCount := int(r.u16(lookup + 4))
+		for j := 0; j < subCount; j++ {
+			offset := lookup + int(r.u16(lookup+6+j*2))
+			t := lookupType
+			if t == gsubExtension {
+				t = r.u16(offset + 2)
+				offset += int(r.u32(offset + 4))
+			}
+			subtables = append(subtables, gsubSubtable{
+				lookupType: t,
+				offset:     offset,
+				coverage:   r.coverage(offset + int(r.u16(offset+2))),
+			})
+		}
+	}
+
+	// Substitutions can chain, repeat until no glyph is added
+	for changed := true; changed; {
+		changed = false
+		add := func(g uint16) {
+			if !keep[g] {
+				keep[g] = true
+				changed = true
+			}
+		}
+
+		for _, st := range subtables {
+			format := r.u16(st.offset)
+			switch st.lookupType {
+			case gsubSingle:
+				for i, g := range st.coverage {
+					if !keep[g] {
+						continue
+					}
+					if format == 1 {
+						add(g + r.u16(st.offset+4))
+					} else if i < int(r.u16(st.offset+4)) {
+						add(r.u16(st.offset + 6 + i*2))
+					}
+				}
+			case gsubMultiple, gsubAlternate:
+				for i, g := range st.coverage {
+					if !keep[g] || i >= int(r.u16(st.offset+4)) {
+						continue
+					}
+					set := st.offset + int(r.u16(st.offset+6+i*2))
+					for j := 0; j < int(r.u16(set)); j++ {
+						add(r.u16(set + 2 + j*2))
+					}
+				}
+			case gsubLigature:
+				for i, g := range st.coverage {
+					if !keep[g] || i >= int(r.u16(st.offset+4)) {
+						continue
+					}
+					set := st.offset + int(r.u16(st.offset+6+i*2))
+					for j := 0; j < int(r.u16(set)); j++ {
+						ligature := set + int(r.u16(set+2+j*2))
+						components := int(r.u16(ligature + 2))
+						all := true
+						for k := 1; k < components; k++ {
+							all = all && keep[r.u16(ligature+4+(k-1)*2)]
+						}
+						if all {
+							add(r.u16(ligature))
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// reader reads big endian values from a font table, returning zero beyond its end.
+type reader []byte
+
+func (r reader) u16(pos int) uint16 {
+	if pos < 0 || pos+2 > len(r) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(r[pos:])
+}
+
+func (r reader) u32(pos int) uint32 {
+	if pos < 0 || pos+4 > len(r) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(r[pos:])
+}
+
+// coverage returns the glyphs of a coverage table in coverage index order.
+func (r reader) coverage(pos int) []uint16 {
+	var glyphs []uint16
+	count := int(r.u16(pos + 2))
+	switch r.u16(pos) {
+	case 1:
+		for i := 0; i < count; i++ {
+			glyphs = append(glyphs, r.u16(pos+4+i*2))
+		}
+	case 2:
+		for i := 0; i < count; i++ {
+			start, end := r.u16(pos+4+i*6), r.u16(pos+6+i*6)
+			for g := int(start); g <= int(end); g++ {
+				glyphs = append(glyphs, uint16(g))
+			}
+		}
+	}
+	return glyphs
+}
@@ -0,0 +1,170 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// Name IDs of the naming table used by renderers to look fonts up
+const (
+	nameFamily            = 1
+	nameFull              = 4
+	namePostScript        = 6
+	nameTypographicFamily = 16
+)
+
+var fontMIMETypes = map[string]string{
+	".ttf": "application/x-truetype-font",
+	".ttc": "application/x-truetype-font",
+	".otf": "application/vnd.ms-opentype",
+}
+
+var errTruncated = errors.New("truncated font file")
+
+// IsFontFile reports whether the file name has the extension of a TrueType or OpenType font.
+func IsFontFile(name string) bool {
+	_, ok := fontMIMETypes[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// MIMEType returns the MIME type mkvmerge uses for a font file.
+func MIMEType(name string) string {
+	return fontMIMETypes[strings.ToLower(filepath.Ext(name))]
+}
+
+// ReadNames returns the names of the fonts in a file, see Names.
+func ReadNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	names, err := Names(data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading font %s: %v", path, err)
+	}
+	return names, nil
+}
+
+// Names returns the family, full and PostScript names of the fonts in a TrueType, OpenType or
+// TrueType collection file, the names subtitle renderers match font requests against.
+func Names(data []byte) ([]string, error) {
+	if len(data) < 12 {
+		return nil, errTruncated
+	}
+
+	offsets := []uint32{0}
+	if string(data[:4]) == "ttcf" {
+		count := binary.BigEndian.Uint32(data[8:])
+		if uint64(len(data)) < 12+uint64(count)*4 {
+			return nil, errTruncated
+		}
+		offsets = offsets[:0]
+		for i := uint32(0); i < count; i++ {
+			offsets = append(offsets, binary.BigEndian.Uint32(data[12+i*4:]))
+		}
+	}
+
+	var names []string
+	for _, offset := range offsets {
+		table, err := findTable(data, offset, "name")
+		if err != nil {
+			return nil, err
+		}
+		fontNames, err := parseNameTable(table)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range fontNames {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// findTable returns the data of a table of the font starting at the given offset.
+func findTable(data []byte, offset uint32, tag string) ([]byte, error) {
+	if uint64(len(data)) < uint64(offset)+12 {
+		return nil, errTruncated
+	}
+
+	switch string(data[offset : offset+4]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+	default:
+		return nil, fmt.Errorf("unsupported font format %q", data[offset:offset+4])
+	}
+
+	numTables := uint32(binary.BigEndian.Uint16(data[offset+4:]))
+	if uint64(len(data)) < uint64(offset)+12+uint64(numTables)*16 {
+		return nil, errTruncated
+	}
+	for i := uint32(0); i < numTables; i++ {
+		record := data[offset+12+i*16:]
+		if string(record[:4]) != tag {
+			continue
+		}
+		start := binary.BigEndian.Uint32(record[8:])
+		length := binary.BigEndian.Uint32(record[12:])
+		if uint64(start)+uint64(length) > uint64(len(data)) {
+			return nil, errTruncated
+		}
+		return data[start : start+length], nil
+	}
+	return nil, fmt.Errorf("font has no %s table", tag)
+}
+
+func parseNameTable(table []byte) ([]string, error) {
+	if len(table) < 6 {
+		return nil, errTruncated
+	}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	storage := int(binary.BigEndian.Uint16(table[4:]))
+	if len(table) < 6+count*12 {
+		return nil, errTruncated
+	}
+
+	var names []string
+	for i := 0; i < count; i++ {
+		record := table[6+i*12:]
+		platform := binary.BigEndian.Uint16(record[0:])
+		nameID := binary.BigEndian.Uint16(record[6:])
+		length := int(binary.BigEndian.Uint16(record[8:]))
+		start := storage + int(binary.BigEndian.Uint16(record[10:]))
+
+		switch nameID {
+		case nameFamily, nameFull, namePostScript, nameTypographicFamily:
+		default:
+			continue
+		}
+		if start+length > len(table) {
+			return nil, errTruncated
+		}
+
+		raw := table[start : start+length]
+		var name string
+		switch platform {
+		case 0, 3: // Unicode and Windows names are UTF-16BE
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[j*2:])
+			}
+			name = string(utf16.Decode(units))
+		case 1: // Macintosh names, Roman encoding is ASCII compatible
+			name = string(raw)
+		default:
+			continue
+		}
+
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,133 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+type testName struct {
+	platform uint16
+	nameID   uint16
+	value    string
+}
+
+// buildNameTable encodes a naming table with Windows (UTF-16BE) and Macintosh (ASCII) records.
+func buildNameTable(names []testName) []byte {
+	var storage []byte
+	table := binary.BigEndian.AppendUint16(nil, 0)
+	table = binary.BigEndian.AppendUint16(table, uint16(len(names)))
+	table = binary.BigEndian.AppendUint16(table, uint16(6+12*len(names)))
+	for _, n := range names {
+		var raw []byte
+		if n.platform == 1 {
+			raw = []byte(n.value)
+		} else {
+			for _, u := range utf16.Encode([]rune(n.value)) {
+				raw = binary.BigEndian.AppendUint16(raw, u)
+			}
+		}
+		for _, v := range []uint16{n.platform, 1, 0x409, n.nameID, uint16(len(raw)), uint16(len(storage))} {
+			table = binary.BigEndian.AppendUint16(table, v)
+		}
+		storage = append(storage, raw...)
+	}
+	return append(table, storage...)
+}
+
+// buildFont writes an sfnt file with the given tables, placed after the table directory.
+func buildFont(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	font := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	font = binary.BigEndian.AppendUint16(font, uint16(len(tags)))
+	font = append(font, make([]byte, 6)...)
+
+	offset := 12 + 16*len(tags)
+	var data []byte
+	for _, tag := range tags {
+		font = append(font, tag...)
+		font = binary.BigEndian.AppendUint32(font, 0)
+		font = binary.BigEndian.AppendUint32(font, uint32(offset+len(data)))
+		font = binary.BigEndian.AppendUint32(font, uint32(len(tables[tag])))
+		data = append(data, tables[tag]...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	return append(font, data...)
+}
+
+func TestNames(t *testing.T) {
+	font := buildFont(map[string][]byte{
+		"head": make([]byte, 54),
+		"name": buildNameTable([]testName{
+			{3, nameFamily, "Open Sans Semibold"},
+			{3, 2, "Regular"},
+			{3, nameFull, "Open Sans Semibold"},
+			{3, namePostScript, "OpenSans-Semibold"},
+			{1, nameTypographicFamily, "Open Sans"},
+		}),
+	})
+
+	names, err := Names(font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Open Sans Semibold", "OpenSans-Semibold", "Open Sans"}; !slices.Equal(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	if _, err := Names(font[:40]); err == nil {
+		t.Error("expected an error for a truncated font")
+	}
+	if _, err := Names([]byte("wOFF0000000000000000")); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestNamesCollection(t *testing.T) {
+	first := buildFont(map[string][]byte{"name": buildNameTable([]testName{{3, nameFamily, "Gothic"}})})
+	second := buildFont(map[string][]byte{"name": buildNameTable([]testName{{3, nameFamily, "PGothic"}})})
+
+	// Table offsets are relative to the start of the collection
+	collection := []byte("ttcf\x00\x01\x00\x00\x00\x00\x00\x02")
+	headerSize := len(collection) + 8
+	collection = binary.BigEndian.AppendUint32(collection, uint32(headerSize))
+	collection = binary.BigEndian.AppendUint32(collection, uint32(headerSize+len(first)))
+	collection = append(collection, relocate(first, headerSize)...)
+	collection = append(collection, relocate(second, headerSize+len(first))...)
+
+	names, err := Names(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Gothic", "PGothic"}; !slices.Equal(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
+
+// relocate moves the table offsets of a font placed at the given position of a collection.
+func relocate(font []byte, position int) []byte {
+	font = slices.Clone(font)
+	numTables := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < numTables; i++ {
+		record := font[12+i*16:]
+		binary.BigEndian.PutUint32(record[8:], binary.BigEndian.Uint32(record[8:])+uint32(position))
+	}
+	return font
+}
+
+func TestIsFontFile(t *testing.T) {
+	if !IsFontFile("OpenSans.TTF") || !IsFontFile("a.otf") || IsFontFile("cover.jpg") {
+		t.Error("unexpected font file detection")
+	}
+	if MIMEType("a.otf") != "application/vnd.ms-opentype" {
+		t.Errorf("unexpected MIME type %s", MIMEType("a.otf"))
+	}
+}
@@ -0,0 +1,254 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// ErrUnsupported is returned when subsetting fonts that don't have TrueType outlines, such as
+// CFF-based OpenType fonts and collections.
+var ErrUnsupported = errors.New("only TrueType outline fonts can be subset")
+
+// Tables dropped from subset fonts: a digital signature is no longer valid once the font changes
+var droppedTables = []string{"DSIG"}
+
+// Subset removes the outlines of the glyphs not needed to render the given characters. Glyph IDs
+// don't change, so the metrics, layout and naming tables are kept as they are and the font keeps
+// its names. Glyphs reachable from the characters through composite glyphs and GSUB substitutions
+// (ligatures, vertical forms, alternates) are kept too.
+func Subset(data []byte, chars []rune) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errTruncated
+	}
+	if string(data[:4]) != "\x00\x01\x00\x00" && string(data[:4]) != "true" {
+		return nil, ErrUnsupported
+	}
+
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range []string{"head", "maxp", "loca", "glyf", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("font has no %s table", tag)
+		}
+	}
+
+	head := slices.Clone(tables["head"])
+	if len(head) < 54 || len(tables["maxp"]) < 6 {
+		return nil, errTruncated
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	longLoca := binary.BigEndian.Uint16(head[50:]) == 1
+
+	offsets, err := parseLoca(tables["loca"], numGlyphs, longLoca)
+	if err != nil {
+		return nil, err
+	}
+	glyf := tables["glyf"]
+	if int(offsets[numGlyphs]) > len(glyf) {
+		return nil, errTruncated
+	}
+
+	cmap, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+
+	// .notdef is always kept, it is drawn for missing characters
+	keep := map[uint16]bool{0: true}
+	for _, r := range chars {
+		if g, ok := cmap[r]; ok && int(g) < numGlyphs {
+			keep[g] = true
+		}
+	}
+	if gsub, ok := tables["GSUB"]; ok {
+		gsubClosure(gsub, keep)
+	}
+	addComponents(glyf, offsets, keep)
+
+	var newGlyf []byte
+	newLoca := make([]byte, 0, (numGlyphs+1)*4)
+	for g := 0; g < numGlyphs; g++ {
+		newLoca = binary.BigEndian.AppendUint32(newLoca, uint32(len(newGlyf)))
+		if keep[uint16(g)] {
+			newGlyf = append(newGlyf, glyf[offsets[g]:offsets[g+1]]...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+	}
+	newLoca = binary.BigEndian.AppendUint32(newLoca, uint32(len(newGlyf)))
+
+	// The new loca table always uses 32-bit offsets
+	binary.BigEndian.PutUint16(head[50:], 1)
+	tables["head"] = head
+	tables["loca"] = newLoca
+	tables["glyf"] = newGlyf
+	for _, tag := range droppedTables {
+		delete(tables, tag)
+	}
+
+	return writeFont(binary.BigEndian.Uint32(data), tables), nil
+}
+
+// readTables returns the tables of a single font file by tag.
+func readTables(data []byte) (map[string][]byte, error) {
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+numTables*16 {
+		return nil, errTruncated
+	}
+
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		record := data[12+i*16:]
+		start := binary.BigEndian.Uint32(record[8:])
+		length := binary.BigEndian.Uint32(record[12:])
+		if uint64(start)+uint64(length) > uint64(len(data)) {
+			return nil, errTruncated
+		}
+		tables[string(record[:4])] = data[start : start+length]
+	}
+	return tables, nil
+}
+
+// writeFont builds a font file with the tables sorted by tag, as the table directory requires, and
+// updates the checksums.
+func writeFont(version uint32, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	numTables := len(tags)
+	entrySelector := bits.Len(uint(numTables)) - 1
+	searchRange := (1 << entrySelector) * 16
+
+	font := binary.BigEndian.AppendUint32(nil, version)
+	font = binary.BigEndian.AppendUint16(font, uint16(numTables))
+	font = binary.BigEndian.AppendUint16(font, uint16(searchRange))
+	font = binary.BigEndian.AppendUint16(font, uint16(entrySelector))
+	font = binary.BigEndian.AppendUint16(font, uint16(numTables*16-searchRange))
+
+	headOffset := -1
+	offset := 12 + numTables*16
+	var body []byte
+	for _, tag := range tags {
+		table := tables[tag]
+		if tag == "head" {
+			// The adjustment is computed over the whole font with this field set to zero
+			table = slices.Clone(table)
+			binary.BigEndian.PutUint32(table[8:], 0)
+			headOffset = offset + len(body)
+		}
+
+		font = append(font, tag...)
+		font = binary.BigEndian.AppendUint32(font, checksum(table))
+		font = binary.BigEndian.AppendUint32(font, uint32(offset+len(body)))
+		font = binary.BigEndian.AppendUint32(font, uint32(len(table)))
+
+		body = append(body, table...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	font = append(font, body...)
+	if headOffset != -1 {
+		binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-checksum(font))
+	}
+	return font
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+func parseLoca(loca []byte, numGlyphs int, long bool) ([]uint32, error) {
+	offsets := make([]uint32, numGlyphs+1)
+	for i := range offsets {
+		if long {
+			if len(loca) < (i+1)*4 {
+				return nil, errTruncated
+			}
+			offsets[i] = binary.BigEndian.Uint32(loca[i*4:])
+		} else {
+			if len(loca) < (i+1)*2 {
+				return nil, errTruncated
+			}
+			offsets[i] = uint32(binary.BigEndian.Uint16(loca[i*2:])) * 2
+		}
+		if i > 0 && offsets[i] < offsets[i-1] {
+			return nil, fmt.Errorf("invalid loca table")
+		}
+	}
+	return offsets, nil
+}
+
+// Composite glyph flags
+const (
+	argsAreWords    = 0x0001
+	haveScale       = 0x0008
+	moreComponents  = 0x0020
+	haveXYScale     = 0x0040
+	haveTwoByTwo    = 0x0080
+	compositeHeader = 10
+)
+
+// addComponents adds the glyphs composite glyphs are built from, recursively.
+func addComponents(glyf []byte, offsets []uint32, keep map[uint16]bool) {
+	pending := make([]uint16, 0, len(keep))
+	for g := range keep {
+		pending = append(pending, g)
+	}
+
+	for len(pending) > 0 {
+		g := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if int(g) >= len(offsets)-1 {
+			continue
+		}
+		data := glyf[offsets[g]:offsets[g+1]]
+		if len(data) < compositeHeader || int16(binary.BigEndian.Uint16(data)) >= 0 {
+			continue
+		}
+
+		for pos := compositeHeader; pos+4 <= len(data); {
+			flags := binary.BigEndian.Uint16(data[pos:])
+			component := binary.BigEndian.Uint16(data[pos+2:])
+			if int(component) < len(offsets)-1 && !keep[component] {
+				keep[component] = true
+				pending = append(pending, component)
+			}
+
+			pos += 4
+			if flags&argsAreWords != 0 {
+				pos += 4
+			} else {
+				pos += 2
+			}
+			switch {
+			case flags&haveScale != 0:
+				pos += 2
+			case flags&haveXYScale != 0:
+				pos += 4
+			case flags&haveTwoByTwo != 0:
+				pos += 8
+			}
+			if flags&moreComponents == 0 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// buildCmap4 encodes a character map with a single format 4 segment mapping the characters from
+// first to last to consecutive glyphs starting at glyph.
+func buildCmap4(first, last rune, glyph uint16) []byte {
+	cmap := binary.BigEndian.AppendUint16(nil, 0)
+	cmap = binary.BigEndian.AppendUint16(cmap, 1)
+	for _, v := range []uint16{3, 1} {
+		cmap = binary.BigEndian.AppendUint16(cmap, v)
+	}
+	cmap = binary.BigEndian.AppendUint32(cmap, 12)
+
+	// Two segments, the last one is the required 0xFFFF terminator
+	for _, v := range []uint16{4, 32, 0, 4, 4, 1, 0, uint16(last), 0xFFFF, 0, uint16(first), 0xFFFF, glyph - uint16(first), 1, 0, 0} {
+		cmap = binary.BigEndian.AppendUint16(cmap, v)
+	}
+	return cmap
+}
+
+// testGlyphs are the outlines of a font where glyph 3 is a composite of glyph 4.
+var testGlyphs = [][]byte{
+	{0, 1, 0, 0, 0, 0, 0, 10, 0, 10, 0, 0},
+	{0, 1, 0, 0, 0, 0, 0, 20, 0, 20, 0, 1},
+	{0, 1, 0, 0, 0, 0, 0, 30, 0, 30, 0, 2},
+	{0xFF, 0xFF, 0, 0, 0, 0, 0, 40, 0, 40, 0, 0, 0, 4, 0, 0},
+	{0, 1, 0, 0, 0, 0, 0, 50, 0, 50, 0, 3},
+	{0, 1, 0, 0, 0, 0, 0, 60, 0, 60, 0, 4},
+}
+
+func buildTrueType() []byte {
+	var glyf, loca []byte
+	for _, g := range testGlyphs {
+		loca = binary.BigEndian.AppendUint16(loca, uint16(len(glyf)/2))
+		glyf = append(glyf, g...)
+	}
+	loca = binary.BigEndian.AppendUint16(loca, uint16(len(glyf)/2))
+
+	maxp := binary.BigEndian.AppendUint32(nil, 0x00005000)
+	maxp = binary.BigEndian.AppendUint16(maxp, uint16(len(testGlyphs)))
+
+	return buildFont(map[string][]byte{
+		"cmap": buildCmap4('A', 'C', 1),
+		"glyf": glyf,
+		"head": make([]byte, 54),
+		"loca": loca,
+		"maxp": maxp,
+		"name": buildNameTable([]testName{{3, nameFamily, "Test Sans"}}),
+		"DSIG": make([]byte, 8),
+	})
+}
+
+func TestSubset(t *testing.T) {
+	font := buildTrueType()
+	subset, err := Subset(font, []rune("AC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := readTables(subset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tables["DSIG"]; ok {
+		t.Error("expected the digital signature to be dropped")
+	}
+	if binary.BigEndian.Uint16(tables["head"][50:]) != 1 {
+		t.Error("expected a long loca table")
+	}
+	if checksum(subset) != 0xB1B0AFBA {
+		t.Errorf("unexpected font checksum %#x", checksum(subset))
+	}
+
+	offsets, err := parseLoca(tables["loca"], len(testGlyphs), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// .notdef, the mapped glyphs and the component of the composite glyph are kept
+	for g, want := range testGlyphs {
+		got := tables["glyf"][offsets[g]:offsets[g+1]]
+		if g == 2 || g == 5 {
+			want = nil
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("expected glyph %d to be %v, got %v", g, want, got)
+		}
+	}
+
+	names, err := Names(subset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"Test Sans"}) {
+		t.Errorf("expected the font names to be kept, got %v", names)
+	}
+}
+
+func TestSubsetUnsupported(t *testing.T) {
+	font := buildTrueType()
+	copy(font, "OTTO")
+	if _, err := Subset(font, []rune("A")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
@@ -2,9 +2,9 @@ module videorepack
 
 go 1.25
 
-require github.com/sirupsen/logrus v1.9.3
-
 require (
-	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
-	golang.org/x/text v0.31.0 // indirect
+	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/text v0.31.0
 )
+
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
@@ -0,0 +1,154 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// AAC audio object types
+const (
+	aacMain = 1
+	aacLC   = 2
+	aacSSR  = 3
+	aacLTP  = 4
+	aacSBR  = 5
+	aacLD   = 23
+	aacPS   = 29
+	aacELD  = 39
+)
+
+var aacProfileNames = map[int]string{
+	aacMain: "Main",
+	aacLC:   "LC",
+	aacSSR:  "SSR",
+	aacLTP:  "LTP",
+	aacSBR:  "HE-AAC",
+	aacPS:   "HE-AACv2",
+	aacLD:   "LD",
+	aacELD:  "ELD",
+}
+
+var aacSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// aacChannels maps the channel configurations of ISO/IEC 14496-3 to their channel count.
+var aacChannels = map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 8, 11: 7, 12: 8, 13: 24, 14: 8}
+
+// aacConfig is the decoded AudioSpecificConfig of an AAC track.
+type aacConfig struct {
+	ObjectType    int // The SBR or PS type for HE-AAC, whatever the core object type
+	SampleRate    int // Core sampling rate, half of the output rate with SBR
+	ChannelConfig int
+}
+
+func parseAACConfig(data []byte) (*aacConfig, error) {
+	br := &bitReader{data: data}
+	objectType := func() int {
+		if t := int(br.u(5)); t != 31 {
+			return t
+		}
+		return 32 + int(br.u(6))
+	}
+	sampleRate := func() int {
+		if index := int(br.u(4)); index < len(aacSampleRates) {
+			return aacSampleRates[index]
+		} else if index == 15 {
+			return int(br.u(24))
+		}
+		return 0
+	}
+
+	config := &aacConfig{}
+	config.ObjectType = objectType()
+	config.SampleRate = sampleRate()
+	config.ChannelConfig = int(br.u(4))
+	if config.ObjectType == aacSBR || config.ObjectType == aacPS {
+		// Explicit hierarchical signalling: the extension comes first, then the core object type
+		sampleRate()
+		objectType()
+	} else if config.ObjectType == aacLC && config.ChannelConfig != 0 {
+		// Backwards compatible signalling: an SBR/PS sync extension after the GASpecificConfig
+		br.skip(1) // frameLengthFlag
+		if br.flag() {
+			br.skip(14) // coreCoderDelay
+		}
+		br.skip(1) // extensionFlag
+		if br.err == nil && len(data)*8-br.pos >= 16 && br.u(11) == 0x2B7 {
+			if objectType() == aacSBR && br.flag() {
+				config.ObjectType = aacSBR
+				sampleRate()
+				if len(data)*8-br.pos >= 12 && br.u(11) == 0x548 && br.flag() {
+					config.ObjectType = aacPS
+				}
+			}
+		}
+	}
+
+	if br.err != nil {
+		return nil, fmt.Errorf("invalid AAC configuration: %v", br.err)
+	}
+	return config, nil
+}
+
+// aacProfileFromCodecID returns the object type of the legacy AAC codec IDs such as
+// A_AAC/MPEG4/LC/SBR, used by old files without codec private data.
+func aacProfileFromCodecID(codecID string) int {
+	switch {
+	case strings.HasSuffix(codecID, "/SBR"):
+		return aacSBR
+	case strings.HasSuffix(codecID, "/LC"):
+		return aacLC
+	case strings.HasSuffix(codecID, "/MAIN"):
+		return aacMain
+	case strings.HasSuffix(codecID, "/SSR"):
+		return aacSSR
+	case strings.HasSuffix(codecID, "/LTP"):
+		return aacLTP
+	}
+	return 0
+}
+
+// opusHeader is the identification header of an Opus stream (RFC 7845).
+type opusHeader struct {
+	Channels      int
+	SampleRate    int // Sampling rate of the original input
+	MappingFamily int
+	Mapping       []byte // Output channel to decoded channel, only for mapping families other than 0
+}
+
+func parseOpusHeader(data []byte) (*opusHeader, error) {
+	if len(data) < 19 || string(data[:8]) != "OpusHead" {
+		return nil, fmt.Errorf("invalid Opus header")
+	}
+	header := &opusHeader{
+		Channels:      int(data[9]),
+		SampleRate:    int(binary.LittleEndian.Uint32(data[12:])),
+		MappingFamily: int(data[18]),
+	}
+	if header.MappingFamily != 0 {
+		if len(data) < 21+header.Channels {
+			return nil, errTruncatedConfig
+		}
+		header.Mapping = data[21 : 21+header.Channels]
+	}
+	return header, nil
+}
+
+// flacStreamInfo is the STREAMINFO metadata block of a FLAC stream.
+type flacStreamInfo struct {
+	SampleRate int
+	Channels   int
+	BitDepth   int
+}
+
+func parseFLACStreamInfo(data []byte) (*flacStreamInfo, error) {
+	if len(data) < 4+4+18 || string(data[:4]) != "fLaC" || data[4]&0x7F != 0 {
+		return nil, fmt.Errorf("invalid FLAC header")
+	}
+	br := &bitReader{data: data[8+10:]}
+	info := &flacStreamInfo{}
+	info.SampleRate = int(br.u(20))
+	info.Channels = int(br.u(3)) + 1
+	info.BitDepth = int(br.u(5)) + 1
+	return info, nil
+}
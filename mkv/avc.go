@@ -0,0 +1,95 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// AVC profiles whose SPS carries the chroma format and bit depth
+var avcHighProfiles = []int{100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135}
+
+var avcProfileNames = map[int]string{
+	66:  "Baseline",
+	77:  "Main",
+	88:  "Extended",
+	100: "High",
+	110: "High 10",
+	122: "High 4:2:2",
+	244: "High 4:4:4 Predictive",
+	44:  "CAVLC 4:4:4 Intra",
+}
+
+// avcSPS holds the fields of an AVC sequence parameter set up to the bit depth.
+type avcSPS struct {
+	Profile        int
+	Constraints    int
+	Level          int
+	ChromaFormat   int
+	BitDepthLuma   int
+	BitDepthChroma int
+}
+
+// parseAVCConfig returns the first SPS of an AVCDecoderConfigurationRecord, the codec private data
+// of AVC tracks.
+func parseAVCConfig(data []byte) (*avcSPS, error) {
+	if len(data) < 8 {
+		return nil, errTruncatedConfig
+	}
+	if data[0] != 1 {
+		return nil, fmt.Errorf("unsupported AVC configuration version %d", data[0])
+	}
+	if data[5]&0x1F == 0 {
+		return nil, fmt.Errorf("AVC configuration without SPS")
+	}
+
+	length := int(binary.BigEndian.Uint16(data[6:]))
+	if 8+length > len(data) {
+		return nil, errTruncatedConfig
+	}
+	return parseAVCSPS(data[8 : 8+length])
+}
+
+func parseAVCSPS(nal []byte) (*avcSPS, error) {
+	if len(nal) < 4 || nal[0]&0x1F != 7 {
+		return nil, fmt.Errorf("not an AVC SPS")
+	}
+	br := &bitReader{data: unescapeRBSP(nal[1:])}
+	sps := &avcSPS{ChromaFormat: 1, BitDepthLuma: 8, BitDepthChroma: 8}
+
+	sps.Profile = int(br.u(8))
+	sps.Constraints = int(br.u(8))
+	sps.Level = int(br.u(8))
+	br.ue() // seq_parameter_set_id
+	if slices.Contains(avcHighProfiles, sps.Profile) {
+		sps.ChromaFormat = int(br.ue())
+		if sps.ChromaFormat == 3 {
+			br.skip(1) // separate_colour_plane_flag
+		}
+		sps.BitDepthLuma = int(br.ue()) + 8
+		sps.BitDepthChroma = int(br.ue()) + 8
+	}
+
+	if br.err != nil {
+		return nil, fmt.Errorf("invalid AVC SPS: %v", br.err)
+	}
+	return sps, nil
+}
+
+func (sps *avcSPS) profileName() string {
+	if sps.Profile == 66 && sps.Constraints&0x40 != 0 {
+		return "Constrained Baseline"
+	}
+	if name, ok := avcProfileNames[sps.Profile]; ok {
+		return name
+	}
+	return fmt.Sprintf("Profile %d", sps.Profile)
+}
+
+func (sps *avcSPS) levelName() string {
+	// Level 1b is signalled as 1.1 with constraint_set3_flag in the Baseline, Main and Extended profiles
+	if sps.Level == 11 && sps.Constraints&0x10 != 0 && (sps.Profile == 66 || sps.Profile == 77 || sps.Profile == 88) {
+		return "1b"
+	}
+	return fmt.Sprintf("%d.%d", sps.Level/10, sps.Level%10)
+}
@@ -0,0 +1,77 @@
+package mkv
+
+import "errors"
+
+var errShortRead = errors.New("unexpected end of bitstream")
+
+// bitReader reads the fields of codec headers, most significant bit first. Reading past the end
+// sets err and returns zeros, so parsers can check it once at the end.
+type bitReader struct {
+	data []byte
+	pos  int // in bits
+	err  error
+}
+
+func (br *bitReader) u(n int) uint64 {
+	if br.pos+n > len(br.data)*8 {
+		br.err = errShortRead
+		br.pos = len(br.data) * 8
+		return 0
+	}
+
+	var v uint64
+	for i := 0; i < n; i++ {
+		bit := br.data[br.pos/8] >> (7 - br.pos%8) & 1
+		v = v<<1 | uint64(bit)
+		br.pos++
+	}
+	return v
+}
+
+func (br *bitReader) flag() bool {
+	return br.u(1) == 1
+}
+
+func (br *bitReader) skip(n int) {
+	br.u(n)
+}
+
+// ue reads an unsigned Exp-Golomb code.
+func (br *bitReader) ue() uint64 {
+	zeros := 0
+	for !br.flag() {
+		if zeros++; zeros > 32 || br.err != nil {
+			br.err = errShortRead
+			return 0
+		}
+	}
+	return 1<<zeros - 1 + br.u(zeros)
+}
+
+// se reads a signed Exp-Golomb code.
+func (br *bitReader) se() int64 {
+	v := br.ue()
+	if v%2 == 1 {
+		return int64(v+1) / 2
+	}
+	return -int64(v / 2)
+}
+
+// unescapeRBSP removes the emulation prevention bytes of a NAL unit.
+func unescapeRBSP(nal []byte) []byte {
+	rbsp := make([]byte, 0, len(nal))
+	zeros := 0
+	for _, b := range nal {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		rbsp = append(rbsp, b)
+	}
+	return rbsp
+}
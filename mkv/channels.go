@@ -0,0 +1,62 @@
+package mkv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// channelLayouts maps channel counts to their usual layout label.
+var channelLayouts = map[int]string{
+	1: "1.0",
+	2: "2.0",
+	3: "2.1",
+	4: "4.0",
+	5: "5.0",
+	6: "5.1",
+	7: "6.1",
+	8: "7.1",
+}
+
+// Channels returns the channel count of an audio track, preferring the one signalled in the codec
+// private data over the container value.
+func (t *Track) Channels() int {
+	if details, err := t.Properties.CodecDetails(); err == nil && details.Channels > 0 {
+		return details.Channels
+	}
+	return t.Properties.AudioChannels
+}
+
+// ChannelLayout returns the channel layout label of an audio track, e.g. "5.1", or an empty
+// string when the channel count is unknown.
+func (t *Track) ChannelLayout() string {
+	channels := t.Channels()
+	if channels <= 0 {
+		return ""
+	}
+	if layout, ok := channelLayouts[channels]; ok {
+		return layout
+	}
+	return fmt.Sprintf("%dch", channels)
+}
+
+// Atmos reports whether mkvmerge found Dolby Atmos objects in the track (TrueHD or E-AC-3 JOC).
+func (t *Track) Atmos() bool {
+	return t.Type == "audio" && strings.Contains(strings.ToLower(t.Codec), "atmos")
+}
+
+// DropLowerChannelAudio removes the audio tracks that have fewer channels than another audio track
+// in the same language, and returns them. Commentary and audio description tracks are left alone.
+func (ec *ExtractedContainer) DropLowerChannelAudio() []ExtractedTrack {
+	best := map[string]int{}
+	for _, t := range ec.Tracks {
+		if t.Info.Type == "audio" && !t.Info.Properties.Supplementary() {
+			lang := t.Info.Properties.LanguageIETF.String()
+			best[lang] = max(best[lang], t.Info.Channels())
+		}
+	}
+
+	return ec.drop(func(t *ExtractedTrack) bool {
+		return t.Info.Type == "audio" && !t.Info.Properties.Supplementary() &&
+			t.Info.Channels() < best[t.Info.Properties.LanguageIETF.String()]
+	})
+}
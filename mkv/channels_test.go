@@ -0,0 +1,59 @@
+package mkv
+
+import (
+	"slices"
+	"testing"
+)
+
+func audioTrack(id int, lang string, codecID string, channels int) ExtractedTrack {
+	tag, _ := FromIETFName(lang)
+	return ExtractedTrack{Info: Track{ID: id, Type: "audio", Properties: TrackProperties{
+		CodecID:              codecID,
+		LanguageIETF:         tag,
+		AudioTrackProperties: AudioTrackProperties{AudioChannels: channels},
+	}}}
+}
+
+func TestChannelLayout(t *testing.T) {
+	for channels, want := range map[int]string{0: "", 1: "1.0", 2: "2.0", 6: "5.1", 8: "7.1", 12: "12ch"} {
+		track := audioTrack(1, "es", "A_EAC3", channels)
+		if got := track.Info.ChannelLayout(); got != want {
+			t.Errorf("%d channels: expected %q, got %q", channels, want, got)
+		}
+	}
+
+	// The Opus header has the real channel count
+	opus := audioTrack(1, "ja", "A_OPUS", 2)
+	opus.Info.Properties.CodecPrivateData = []byte("OpusHead\x01\x06\x38\x01\x80\xbb\x00\x00\x00\x00\x01\x04\x02\x00\x04\x01\x02\x03\x05")
+	if got := opus.Info.ChannelLayout(); got != "5.1" {
+		t.Errorf("expected the Opus layout to be 5.1, got %q", got)
+	}
+
+	atmos := audioTrack(1, "en", "A_TRUEHD", 8)
+	atmos.Info.Codec = "TrueHD Atmos"
+	if metadata := atmos.Info.NamingMetadata(); !slices.Equal(metadata, []string{"English (en)", "TrueHD Atmos 7.1"}) {
+		t.Errorf("unexpected naming metadata %v", metadata)
+	}
+}
+
+func TestDropLowerChannelAudio(t *testing.T) {
+	cont := ExtractedContainer{Tracks: []ExtractedTrack{
+		{Info: Track{ID: 0, Type: "video"}},
+		audioTrack(1, "es", "A_AC3", 2),
+		audioTrack(2, "es", "A_EAC3", 6),
+		audioTrack(3, "es", "A_DTS", 6),
+		audioTrack(4, "ja", "A_AAC", 2),
+	}}
+
+	var dropped []int
+	for _, t := range cont.DropLowerChannelAudio() {
+		dropped = append(dropped, t.Info.ID)
+	}
+	var kept []int
+	for _, t := range cont.Tracks {
+		kept = append(kept, t.Info.ID)
+	}
+	if !slices.Equal(dropped, []int{1}) || !slices.Equal(kept, []int{0, 2, 3, 4}) {
+		t.Errorf("unexpected tracks: dropped %v, kept %v", dropped, kept)
+	}
+}
@@ -0,0 +1,136 @@
+package mkv
+
+import "strings"
+
+// CodecClass tells how a codec stores its data.
+type CodecClass string
+
+const (
+	CodecLossy    CodecClass = "lossy"
+	CodecLossless CodecClass = "lossless"
+	CodecText     CodecClass = "text"   // Text subtitles
+	CodecBitmap   CodecClass = "bitmap" // Image subtitles
+)
+
+// Codec describes a Matroska codec.
+type Codec struct {
+	Name      string // Display name used in file names
+	Type      string // Track type: video, audio or subtitles
+	Class     CodecClass
+	Extension string // Extension of the file mkvextract writes
+	// mkvextract can write the track to a file mkvmerge reads back. Other tracks can't be repacked.
+	Extractable bool
+}
+
+// CodecRegistry maps Matroska codec IDs to their description. IDs ending in "/" cover every codec
+// ID starting with them, e.g. "A_PCM/" matches A_PCM/INT/LIT and A_PCM/FLOAT/IEEE.
+type CodecRegistry map[string]Codec
+
+// Codecs lists the Matroska codecs, see https://www.matroska.org/technical/codec_specs.html
+var Codecs = CodecRegistry{
+	"V_MPEG4/ISO/AVC":  {"H264", "video", CodecLossy, "h264", true},
+	"V_MPEGH/ISO/HEVC": {"HEVC", "video", CodecLossy, "hevc", true},
+	"V_AV1":            {"AV1", "video", CodecLossy, "ivf", true},
+	"V_VP9":            {"VP9", "video", CodecLossy, "ivf", true},
+	"V_VP8":            {"VP8", "video", CodecLossy, "ivf", true},
+	"V_MPEG1":          {"MPEG-1", "video", CodecLossy, "m1v", true},
+	"V_MPEG2":          {"MPEG-2", "video", CodecLossy, "m2v", true},
+	"V_MPEG4/ISO/":     {"MPEG-4", "video", CodecLossy, "avi", false},
+	"V_MS/VFW/FOURCC":  {"VFW", "video", CodecLossy, "avi", true},
+	"V_THEORA":         {"Theora", "video", CodecLossy, "ogv", true},
+	"V_REAL/":          {"RealVideo", "video", CodecLossy, "rm", true},
+	"V_PRORES":         {"ProRes", "video", CodecLossy, "bin", false},
+	"V_FFV1":           {"FFV1", "video", CodecLossless, "bin", false},
+	"V_UNCOMPRESSED":   {"RAW", "video", CodecLossless, "bin", false},
+
+	"A_AAC":        {"AAC", "audio", CodecLossy, "aac", true},
+	"A_AAC/":       {"AAC", "audio", CodecLossy, "aac", true},
+	"A_AC3":        {"AC3", "audio", CodecLossy, "ac3", true},
+	"A_AC3/":       {"AC3", "audio", CodecLossy, "ac3", true},
+	"A_EAC3":       {"EAC3", "audio", CodecLossy, "eac3", true},
+	"A_DTS":        {"DTS", "audio", CodecLossy, "dts", true},
+	"A_DTS/":       {"DTS", "audio", CodecLossy, "dts", true},
+	"A_TRUEHD":     {"TrueHD", "audio", CodecLossless, "thd", true},
+	"A_MLP":        {"MLP", "audio", CodecLossless, "mlp", true},
+	"A_FLAC":       {"FLAC", "audio", CodecLossless, "flac", true},
+	"A_ALAC":       {"ALAC", "audio", CodecLossless, "caf", true},
+	"A_PCM/":       {"PCM", "audio", CodecLossless, "wav", true},
+	"A_WAVPACK4":   {"WavPack", "audio", CodecLossless, "wv", true},
+	"A_TTA1":       {"TTA", "audio", CodecLossless, "tta", true},
+	"A_OPUS":       {"Opus", "audio", CodecLossy, "opus", true},
+	"A_VORBIS":     {"Vorbis", "audio", CodecLossy, "ogg", true},
+	"A_MPEG/L3":    {"MP3", "audio", CodecLossy, "mp3", true},
+	"A_MPEG/L2":    {"MP2", "audio", CodecLossy, "mp2", true},
+	"A_MPEG/L1":    {"MP1", "audio", CodecLossy, "mp1", true},
+	"A_REAL/":      {"RealAudio", "audio", CodecLossy, "ra", true},
+	"A_MS/ACM":     {"ACM", "audio", CodecLossy, "bin", false},
+	"A_QUICKTIME/": {"QuickTime", "audio", CodecLossy, "bin", false},
+
+	"S_TEXT/UTF8":   {"SRT", "subtitles", CodecText, "srt", true},
+	"S_TEXT/ASCII":  {"SRT", "subtitles", CodecText, "srt", true},
+	"S_TEXT/ASS":    {"ASS", "subtitles", CodecText, "ass", true},
+	"S_TEXT/SSA":    {"SSA", "subtitles", CodecText, "ssa", true},
+	"S_ASS":         {"ASS", "subtitles", CodecText, "ass", true},
+	"S_SSA":         {"SSA", "subtitles", CodecText, "ssa", true},
+	"S_TEXT/WEBVTT": {"VTT", "subtitles", CodecText, "vtt", true},
+	"S_TEXT/USF":    {"USF", "subtitles", CodecText, "usf", true},
+	"S_HDMV/TEXTST": {"TextST", "subtitles", CodecText, "textst", true},
+	"S_KATE":        {"Kate", "subtitles", CodecText, "ogg", true},
+	"S_HDMV/PGS":    {"PGS", "subtitles", CodecBitmap, "sup", true},
+	// mkvextract writes the index with this extension and the bitmaps to a .sub file next to it
+	"S_VOBSUB":    {"VobSub", "subtitles", CodecBitmap, "idx", true},
+	"S_DVBSUB":    {"DVB", "subtitles", CodecBitmap, "bin", false},
+	"S_IMAGE/BMP": {"BMP", "subtitles", CodecBitmap, "bin", false},
+}
+
+// Lookup returns the codec of a Matroska codec ID, matching the longest registered prefix when the
+// exact ID isn't registered.
+func (cr CodecRegistry) Lookup(codecID string) (Codec, bool) {
+	if c, ok := cr[codecID]; ok {
+		return c, true
+	}
+
+	var best string
+	for id := range cr {
+		if strings.HasSuffix(id, "/") && strings.HasPrefix(codecID, id) && len(id) > len(best) {
+			best = id
+		}
+	}
+	if best == "" {
+		return Codec{}, false
+	}
+	return cr[best], true
+}
+
+// Codec returns the registered codec of the track. Unknown codecs are still handed to mkvextract.
+func (tp *TrackProperties) Codec() Codec {
+	if c, ok := Codecs.Lookup(tp.CodecID); ok {
+		return c
+	}
+	return Codec{Name: "BIN", Class: CodecLossy, Extension: "bin", Extractable: true}
+}
+
+// CodecName returns the display name of the track codec, telling apart the profiles that share a
+// codec ID, such as DTS-HD Master Audio or HE-AAC.
+func (t *Track) CodecName() string {
+	name := t.Properties.Codec().Name
+	codec := strings.ToLower(t.Codec)
+	switch {
+	case name == "DTS" && strings.Contains(codec, "master audio"):
+		return "DTS-HD MA"
+	case name == "DTS" && strings.Contains(codec, "high resolution"):
+		return "DTS-HD HRA"
+	case name == "DTS" && strings.Contains(codec, "express"):
+		return "DTS Express"
+	case name == "DTS" && strings.Contains(codec, "dts-es"):
+		return "DTS-ES"
+	case t.Atmos():
+		return name + " Atmos"
+	case name == "AAC":
+		// HE-AAC is named after its profile, plain AAC is LC
+		if details, err := t.Properties.CodecDetails(); err == nil && strings.HasPrefix(details.Profile, "HE-AAC") {
+			return details.Profile
+		}
+	}
+	return name
+}
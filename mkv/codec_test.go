@@ -0,0 +1,55 @@
+package mkv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCodecLookup(t *testing.T) {
+	for codecID, want := range map[string]struct {
+		name      string
+		extension string
+		class     CodecClass
+	}{
+		"V_MPEG4/ISO/AVC":  {"H264", "h264", CodecLossy},
+		"V_MPEG4/ISO/ASP":  {"MPEG-4", "avi", CodecLossy},
+		"A_PCM/INT/LIT":    {"PCM", "wav", CodecLossless},
+		"A_AAC/MPEG4/LC":   {"AAC", "aac", CodecLossy},
+		"A_TRUEHD":         {"TrueHD", "thd", CodecLossless},
+		"S_VOBSUB":         {"VobSub", "idx", CodecBitmap},
+		"S_TEXT/WEBVTT":    {"VTT", "vtt", CodecText},
+		"V_QUICKTIME/SVQ3": {"BIN", "bin", CodecLossy},
+	} {
+		tp := TrackProperties{CodecID: codecID}
+		c := tp.Codec()
+		if c.Name != want.name || tp.FileExtension() != want.extension || c.Class != want.class {
+			t.Errorf("%s: unexpected codec %+v", codecID, c)
+		}
+	}
+
+	if _, ok := Codecs.Lookup("A_PCMX"); ok {
+		t.Error("expected prefixes to only match whole codec ID parts")
+	}
+	if c, _ := Codecs.Lookup("S_DVBSUB"); c.Extractable {
+		t.Error("expected DVB subtitles not to be extractable")
+	}
+}
+
+func TestCodecName(t *testing.T) {
+	for codec, want := range map[string]string{
+		"DTS-HD Master Audio":          "DTS-HD MA",
+		"DTS-HD High Resolution Audio": "DTS-HD HRA",
+		"DTS-ES":                       "DTS-ES",
+		"DTS":                          "DTS",
+	} {
+		track := Track{Type: "audio", Codec: codec, Properties: TrackProperties{CodecID: "A_DTS"}}
+		if got := track.CodecName(); got != want {
+			t.Errorf("%s: expected %s, got %s", codec, want, got)
+		}
+	}
+
+	track := Track{Type: "audio", Codec: "TrueHD Atmos", Properties: TrackProperties{CodecID: "A_TRUEHD"}}
+	if metadata := track.NamingMetadata(); !slices.Equal(metadata, []string{"TrueHD Atmos"}) {
+		t.Errorf("unexpected naming metadata %v", metadata)
+	}
+}
@@ -1,16 +1,58 @@
 package mkv
 
-import "slices"
+import (
+	"os"
+	"time"
+)
 
 type TrackOperations struct {
-	Delay int64 // in milliseconds
+	Delay   int64   // in milliseconds
+	Stretch float64 // Timestamps are multiplied by this factor before adding the delay, 0 keeps them
+}
+
+// Retimed reports whether the operations change the track timestamps.
+func (to *TrackOperations) Retimed() bool {
+	return to.Delay != 0 || to.Stretch != 0 && to.Stretch != 1
 }
 
 type ExtractedTrack struct {
 	Info        Track
 	Operations  TrackOperations
 	FilePath    string
+	ExtraFiles  []string // Other files FilePath refers to, such as the .sub file of a VobSub index
 	TimeMapPath string
+	Sidecar     string // Path of the sidecar file next to the video the track comes from, if any
+}
+
+// Files returns the extracted files of the track: the file passed to mkvmerge and the ones it refers to.
+func (et *ExtractedTrack) Files() []string {
+	return append([]string{et.FilePath}, et.ExtraFiles...)
+}
+
+// Replace swaps the extracted file of the track for a converted one, updating the codec accordingly.
+func (et *ExtractedTrack) Replace(filePath string, codecID string) {
+	et.FilePath = filePath
+	et.ExtraFiles = nil
+	et.Info.Codec = codecID
+	et.Info.Properties.CodecID = codecID
+	et.Info.Properties.CodecPrivateData = nil
+	et.Info.Properties.CodecPrivateLength = 0
+	et.Info.Properties.TagBps = 0
+}
+
+// Bitrate returns the track bitrate in bits per second. The statistics tags are used when present,
+// otherwise it is estimated from the extracted file size and the container duration.
+func (et *ExtractedTrack) Bitrate(duration time.Duration) int64 {
+	if et.Info.Properties.TagBps > 0 {
+		return int64(et.Info.Properties.TagBps)
+	}
+
+	inf, err := os.Stat(et.FilePath)
+	if err != nil || duration <= 0 {
+		return 0
+	}
+
+	return int64(float64(inf.Size()*8) / duration.Seconds())
 }
 
 type ExtractedAttachment struct {
@@ -22,13 +64,64 @@ type ExtractedContainer struct {
 	Tracks      []ExtractedTrack
 	Attachments []ExtractedAttachment
 	Chapters    string
+	Duration    time.Duration
+	TrackOrder  []int // IDs of the tracks in output order, empty to merge them in the order of Tracks
+}
+
+// AddTracks adds external tracks to the container, keeping the tracks order.
+func (ec *ExtractedContainer) AddTracks(tracks ...ExtractedTrack) {
+	ec.Tracks = sortedExtractedTracks(append(ec.Tracks, tracks...))
+}
+
+// SortTracks restores the tracks order after changing the flags it depends on.
+func (ec *ExtractedContainer) SortTracks() {
+	ec.Tracks = sortedExtractedTracks(ec.Tracks)
 }
 
-func (ec *ExtractedContainer) GetDefaultTracks(mainLang LocaleInfo) []int {
+// DropCommentary removes the commentary tracks from the container and returns them.
+func (ec *ExtractedContainer) DropCommentary() []ExtractedTrack {
+	return ec.drop(func(t *ExtractedTrack) bool { return t.Info.Properties.FlagCommentary })
+}
+
+// DropAudioDescription removes the audio description tracks from the container and returns them.
+func (ec *ExtractedContainer) DropAudioDescription() []ExtractedTrack {
+	return ec.drop(func(t *ExtractedTrack) bool { return t.Info.Properties.FlagVisualImpaired })
+}
+
+func (ec *ExtractedContainer) drop(match func(t *ExtractedTrack) bool) []ExtractedTrack {
+	var kept, dropped []ExtractedTrack
+	for i := range ec.Tracks {
+		if match(&ec.Tracks[i]) {
+			dropped = append(dropped, ec.Tracks[i])
+		} else {
+			kept = append(kept, ec.Tracks[i])
+		}
+	}
+	ec.Tracks = kept
+	return dropped
+}
+
+// NextTrackID returns an unused track ID for tracks added to the container.
+func (ec *ExtractedContainer) NextTrackID() int {
+	next := 0
+	for _, t := range ec.Tracks {
+		if t.Info.ID >= next {
+			next = t.Info.ID + 1
+		}
+	}
+	return next
+}
+
+// GetDefaultTracks returns the IDs of the last video track and the default audio and subtitle
+// tracks chosen by the strategy. Commentary and audio description tracks are never chosen.
+func (ec *ExtractedContainer) GetDefaultTracks(strategy DefaultTrackStrategy) []int {
 	var videoTrack *ExtractedTrack
 	audioTracks := make([]ExtractedTrack, 0)
 	subtitleTracks := make([]ExtractedTrack, 0)
 	for i, lang := range ec.Tracks {
+		if lang.Info.Properties.Supplementary() {
+			continue
+		}
 		if lang.Info.Type == "video" {
 			videoTrack = &ec.Tracks[i]
 		} else if lang.Info.Type == "audio" {
@@ -38,33 +131,8 @@ func (ec *ExtractedContainer) GetDefaultTracks(mainLang LocaleInfo) []int {
 		}
 	}
 
-	var audioTrack *ExtractedTrack
-	if pos := slices.IndexFunc(audioTracks, func(t ExtractedTrack) bool {
-		return t.Info.Properties.LanguageIETF == mainLang
-	}); pos != -1 {
-		audioTrack = &audioTracks[pos]
-	} else if pos := slices.IndexFunc(audioTracks, func(t ExtractedTrack) bool {
-		baseAudioLang, _ := t.Info.Properties.LanguageIETF.Base()
-		baseTargetLang, _ := mainLang.Base()
-
-		return baseAudioLang == baseTargetLang
-	}); pos != -1 {
-		audioTrack = &audioTracks[pos]
-	} else if pos := slices.IndexFunc(audioTracks, func(t ExtractedTrack) bool {
-		return t.Info.Properties.DefaultTrack
-	}); pos != -1 {
-		audioTrack = &audioTracks[pos]
-	} else if len(audioTracks) > 0 {
-		audioTrack = &audioTracks[0]
-	}
-
-	audioInMainLang := audioTrack != nil && audioTrack.Info.Properties.LanguageIETF == mainLang
-	var subtitleTrack *ExtractedTrack
-	if pos := slices.IndexFunc(subtitleTracks, func(t ExtractedTrack) bool {
-		return t.Info.Properties.LanguageIETF == mainLang && t.Info.Properties.ForcedTrack == audioInMainLang
-	}); pos != -1 {
-		subtitleTrack = &subtitleTracks[pos]
-	}
+	audioTrack := strategy.DefaultAudio(audioTracks)
+	subtitleTrack := strategy.DefaultSubtitles(subtitleTracks, audioTrack)
 
 	var selectedIndexes []int
 	if videoTrack != nil {
@@ -0,0 +1,60 @@
+package mkv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommentaryTracks(t *testing.T) {
+	spanish, _ := FromIETFName("es-ES")
+	commentary := audioTrack(1, "es-ES", "A_AC3", 2)
+	commentary.Info.Properties.FlagCommentary = true
+	commentarySubs := ExtractedTrack{Info: Track{ID: 4, Type: "subtitles", Properties: TrackProperties{LanguageIETF: spanish, FlagCommentary: true}}}
+
+	cont := ExtractedContainer{Tracks: []ExtractedTrack{
+		{Info: Track{ID: 0, Type: "video"}},
+		commentary,
+		audioTrack(2, "es-ES", "A_EAC3", 6),
+		audioTrack(3, "ja", "A_AAC", 2),
+		commentarySubs,
+		{Info: Track{ID: 5, Type: "subtitles", Properties: TrackProperties{LanguageIETF: spanish, ForcedTrack: true}}},
+	}}
+	cont.SortTracks()
+
+	var order []int
+	for _, track := range cont.Tracks {
+		order = append(order, track.Info.ID)
+	}
+	if !slices.Equal(order, []int{0, 2, 3, 1, 5, 4}) {
+		t.Errorf("expected commentary tracks last, got %v", order)
+	}
+
+	if defaults := cont.GetDefaultTracks(DubFirst{Languages: []LocaleInfo{spanish}}); !slices.Equal(defaults, []int{0, 2, 5}) {
+		t.Errorf("expected default tracks [0 2 5], got %v", defaults)
+	}
+
+	dropped := cont.DropCommentary()
+	if len(dropped) != 2 || dropped[0].Info.ID != 1 || dropped[1].Info.ID != 4 {
+		t.Errorf("unexpected dropped tracks %v", dropped)
+	}
+	if len(cont.Tracks) != 4 {
+		t.Errorf("expected 4 tracks left, got %d", len(cont.Tracks))
+	}
+}
+
+func TestAudioDescriptionTracks(t *testing.T) {
+	spanish, _ := FromIETFName("es-ES")
+	description := audioTrack(1, "es-ES", "A_AAC", 2)
+	description.Info.Properties.FlagVisualImpaired = true
+
+	cont := ExtractedContainer{Tracks: []ExtractedTrack{description, audioTrack(2, "es-ES", "A_AC3", 6)}}
+	if defaults := cont.GetDefaultTracks(DubFirst{Languages: []LocaleInfo{spanish}}); !slices.Equal(defaults, []int{2}) {
+		t.Errorf("expected default tracks [2], got %v", defaults)
+	}
+	if dropped := cont.DropLowerChannelAudio(); len(dropped) != 0 {
+		t.Errorf("expected the audio description to be kept, dropped %v", dropped)
+	}
+	if dropped := cont.DropAudioDescription(); len(dropped) != 1 || dropped[0].Info.ID != 1 {
+		t.Errorf("unexpected dropped tracks %v", dropped)
+	}
+}
@@ -0,0 +1,169 @@
+package mkv
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Names of the default track strategies
+const (
+	StrategyDubFirst      = "dub_first"
+	StrategyOriginalSubs  = "original_subs"
+	StrategyOriginalOnly  = "original_only"
+	StrategyLanguageChain = "language_chain"
+)
+
+// DefaultTrackStrategy chooses the default video, audio and subtitle tracks of a file. Commentary
+// and audio description tracks are never passed to it.
+type DefaultTrackStrategy interface {
+	// DefaultAudio returns the default audio track, or nil.
+	DefaultAudio(audio []ExtractedTrack) *ExtractedTrack
+	// DefaultSubtitles returns the default subtitle track for the chosen audio track, or nil.
+	DefaultSubtitles(subtitles []ExtractedTrack, audio *ExtractedTrack) *ExtractedTrack
+}
+
+// NewDefaultTrackStrategy returns the strategy with the given name. The language chain strategy
+// uses the chain of main language fallbacks, or the main language alone when it's empty.
+func NewDefaultTrackStrategy(name string, main LocaleInfo, original LocaleInfo, chain []LocaleInfo) (DefaultTrackStrategy, error) {
+	switch name {
+	case StrategyDubFirst, "":
+		return DubFirst{Languages: []LocaleInfo{main}}, nil
+	case StrategyLanguageChain:
+		if len(chain) == 0 {
+			chain = []LocaleInfo{main}
+		}
+		return DubFirst{Languages: chain}, nil
+	case StrategyOriginalSubs:
+		return OriginalWithSubs{Main: main, Original: original}, nil
+	case StrategyOriginalOnly:
+		return OriginalOnly{Original: original}, nil
+	}
+	return nil, fmt.Errorf("unknown default track strategy %q", name)
+}
+
+// DubFirst plays the audio in the first available language of the chain, with the forced subtitles
+// in that language. Without such audio, the audio flagged as default, usually the original one, is
+// played with full subtitles in the chain languages.
+type DubFirst struct {
+	Languages []LocaleInfo // Main language and its fallbacks, in order of preference
+}
+
+func (s DubFirst) DefaultAudio(audio []ExtractedTrack) *ExtractedTrack {
+	if t := findByLanguage(audio, s.Languages, nil); t != nil {
+		return t
+	}
+	return flaggedAudio(audio)
+}
+
+func (s DubFirst) DefaultSubtitles(subtitles []ExtractedTrack, audio *ExtractedTrack) *ExtractedTrack {
+	dubbed := audio != nil && matchLanguage(audio.Info.Properties.LanguageIETF, s.Languages) != -1
+	return findSubtitles(subtitles, s.Languages, dubbed)
+}
+
+// OriginalWithSubs (VOS) plays the original audio with full subtitles in the main language, or the
+// forced ones when the original language is the main one.
+type OriginalWithSubs struct {
+	Main     LocaleInfo
+	Original LocaleInfo
+}
+
+func (s OriginalWithSubs) DefaultAudio(audio []ExtractedTrack) *ExtractedTrack {
+	return fallbackAudio(audio, s.Original)
+}
+
+func (s OriginalWithSubs) DefaultSubtitles(subtitles []ExtractedTrack, audio *ExtractedTrack) *ExtractedTrack {
+	languages := []LocaleInfo{s.Main}
+	understood := audio != nil && matchLanguage(audio.Info.Properties.LanguageIETF, languages) != -1
+	return findSubtitles(subtitles, languages, understood)
+}
+
+// OriginalOnly plays the original audio without subtitles, except the forced ones in the language of
+// the audio.
+type OriginalOnly struct {
+	Original LocaleInfo
+}
+
+func (s OriginalOnly) DefaultAudio(audio []ExtractedTrack) *ExtractedTrack {
+	return fallbackAudio(audio, s.Original)
+}
+
+func (s OriginalOnly) DefaultSubtitles(subtitles []ExtractedTrack, audio *ExtractedTrack) *ExtractedTrack {
+	if audio == nil {
+		return nil
+	}
+	languages := []LocaleInfo{audio.Info.Properties.LanguageIETF}
+	return findByLanguage(subtitles, languages, func(t *ExtractedTrack) bool { return t.Info.Properties.ForcedTrack })
+}
+
+// fallbackAudio returns the audio track in the original language, or the flagged one.
+func fallbackAudio(audio []ExtractedTrack, original LocaleInfo) *ExtractedTrack {
+	if t := findByLanguage(audio, []LocaleInfo{original}, nil); t != nil {
+		return t
+	}
+	return flaggedAudio(audio)
+}
+
+// flaggedAudio returns the audio track flagged as default or the first one.
+func flaggedAudio(audio []ExtractedTrack) *ExtractedTrack {
+	for i := range audio {
+		if audio[i].Info.Properties.DefaultTrack {
+			return &audio[i]
+		}
+	}
+	if len(audio) > 0 {
+		return &audio[0]
+	}
+	return nil
+}
+
+// findSubtitles returns the forced or the full subtitles in the first available language. SDH
+// subtitles are only chosen when there is no other track.
+func findSubtitles(subtitles []ExtractedTrack, languages []LocaleInfo, forced bool) *ExtractedTrack {
+	if t := findByLanguage(subtitles, languages, func(t *ExtractedTrack) bool {
+		return t.Info.Properties.ForcedTrack == forced && !t.Info.Properties.FlagHearingImpaired
+	}); t != nil {
+		return t
+	}
+	return findByLanguage(subtitles, languages, func(t *ExtractedTrack) bool {
+		return t.Info.Properties.ForcedTrack == forced
+	})
+}
+
+// findByLanguage returns the first track accepted by the filter in the first language of the list
+// that has one. Exact tags are tried before the base languages, so es-ES is preferred to es-419.
+func findByLanguage(tracks []ExtractedTrack, languages []LocaleInfo, accept func(t *ExtractedTrack) bool) *ExtractedTrack {
+	var best *ExtractedTrack
+	bestRank := -1
+	for i := range tracks {
+		t := &tracks[i]
+		if accept != nil && !accept(t) {
+			continue
+		}
+		if rank := matchLanguage(t.Info.Properties.LanguageIETF, languages); rank != -1 && (best == nil || rank < bestRank) {
+			best, bestRank = t, rank
+		}
+	}
+	return best
+}
+
+// matchLanguage ranks a language against a list of preferred ones: the position of the same tag,
+// or after all of them the position of the same base language, or -1. Undetermined languages never
+// match.
+func matchLanguage(lang LocaleInfo, languages []LocaleInfo) int {
+	base, confidence := lang.Base()
+	if confidence != language.Exact {
+		return -1
+	}
+	for i, l := range languages {
+		if l == lang {
+			return i
+		}
+	}
+	for i, l := range languages {
+		if lBase, lConfidence := l.Base(); lBase == base && lConfidence == language.Exact {
+			return len(languages) + i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,125 @@
+package mkv
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// Anime with Japanese audio, a European Spanish dub and Spanish forced, full and SDH subtitles
+const animeIdentityJSON = `{"tracks": [
+  {"id": 0, "type": "video", "properties": {"language_ietf": "und"}},
+  {"id": 1, "type": "audio", "properties": {"language_ietf": "ja", "default_track": true}},
+  {"id": 2, "type": "audio", "properties": {"language_ietf": "es-ES"}},
+  {"id": 3, "type": "audio", "properties": {"language_ietf": "es-ES", "flag_commentary": true}},
+  {"id": 4, "type": "subtitles", "properties": {"language_ietf": "es-ES", "flag_hearing_impaired": true}},
+  {"id": 5, "type": "subtitles", "properties": {"language_ietf": "es-ES"}},
+  {"id": 6, "type": "subtitles", "properties": {"language_ietf": "es-ES", "forced_track": true}},
+  {"id": 7, "type": "subtitles", "properties": {"language_ietf": "en"}}
+]}`
+
+// Film with English audio, a Latin American Spanish dub and Spanish subtitles of both regions
+const latinIdentityJSON = `{"tracks": [
+  {"id": 0, "type": "video", "properties": {"language_ietf": "und"}},
+  {"id": 1, "type": "audio", "properties": {"language_ietf": "en"}},
+  {"id": 2, "type": "audio", "properties": {"language_ietf": "es-419"}},
+  {"id": 3, "type": "audio", "properties": {"language_ietf": "es-419", "flag_visual_impaired": true}},
+  {"id": 4, "type": "subtitles", "properties": {"language_ietf": "es-419", "forced_track": true}},
+  {"id": 5, "type": "subtitles", "properties": {"language_ietf": "es-419"}},
+  {"id": 6, "type": "subtitles", "properties": {"language_ietf": "es"}},
+  {"id": 7, "type": "subtitles", "properties": {"language_ietf": "en", "forced_track": true}}
+]}`
+
+func fixtureContainer(t *testing.T, identityJSON string) ExtractedContainer {
+	t.Helper()
+	var identity Identity
+	if err := json.Unmarshal([]byte(identityJSON), &identity); err != nil {
+		t.Fatal(err)
+	}
+	var cont ExtractedContainer
+	for _, track := range identity.Tracks {
+		cont.Tracks = append(cont.Tracks, ExtractedTrack{Info: track})
+	}
+	return cont
+}
+
+func TestDefaultTrackStrategies(t *testing.T) {
+	tag := func(name string) LocaleInfo {
+		l, _ := FromIETFName(name)
+		return l
+	}
+	spanish, english, japanese := tag("es-ES"), tag("en"), tag("ja")
+	chain := []LocaleInfo{tag("es-ES"), tag("es"), tag("es-419")}
+
+	for _, c := range []struct {
+		name     string
+		identity string
+		strategy string
+		original LocaleInfo
+		want     []int
+	}{
+		{"anime dub first", animeIdentityJSON, StrategyDubFirst, japanese, []int{0, 2, 6}},
+		{"anime VOS", animeIdentityJSON, StrategyOriginalSubs, japanese, []int{0, 1, 5}},
+		{"anime original only", animeIdentityJSON, StrategyOriginalOnly, japanese, []int{0, 1}},
+		{"anime chain", animeIdentityJSON, StrategyLanguageChain, japanese, []int{0, 2, 6}},
+		// The Latin American dub is Spanish too, with the forced subtitles of its region
+		{"latin dub first", latinIdentityJSON, StrategyDubFirst, english, []int{0, 2, 4}},
+		{"latin VOS", latinIdentityJSON, StrategyOriginalSubs, english, []int{0, 1, 5}},
+		{"latin original only", latinIdentityJSON, StrategyOriginalOnly, english, []int{0, 1, 7}},
+		{"latin chain", latinIdentityJSON, StrategyLanguageChain, english, []int{0, 2, 4}},
+	} {
+		cont := fixtureContainer(t, c.identity)
+		strategy, err := NewDefaultTrackStrategy(c.strategy, spanish, c.original, chain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cont.GetDefaultTracks(strategy); !slices.Equal(got, c.want) {
+			t.Errorf("%s: expected default tracks %v, got %v", c.name, c.want, got)
+		}
+	}
+
+	if _, err := NewDefaultTrackStrategy("dubbed", spanish, japanese, nil); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestLanguageChainOrder(t *testing.T) {
+	cont := fixtureContainer(t, latinIdentityJSON)
+	spanish, _ := FromIETFName("es-ES")
+	generic, _ := FromIETFName("es")
+
+	// Without a dub in the chain languages, full subtitles follow the chain: es before es-419
+	strategy := DubFirst{Languages: []LocaleInfo{spanish, generic}}
+	cont.Tracks = slices.DeleteFunc(cont.Tracks, func(t ExtractedTrack) bool { return t.Info.ID == 2 })
+	if got := cont.GetDefaultTracks(strategy); !slices.Equal(got, []int{0, 1, 6}) {
+		t.Errorf("expected default tracks [0 1 6], got %v", got)
+	}
+}
+
+func TestDefaultAudioFallback(t *testing.T) {
+	cont := fixtureContainer(t, `{"tracks": [
+  {"id": 0, "type": "video", "properties": {"language_ietf": "und"}},
+  {"id": 1, "type": "video", "properties": {"language_ietf": "und"}},
+  {"id": 2, "type": "audio", "properties": {"language_ietf": "fr", "default_track": true}},
+  {"id": 3, "type": "audio", "properties": {"language_ietf": "en"}},
+  {"id": 4, "type": "subtitles", "properties": {"language_ietf": "es-ES"}}
+]}`)
+	spanish, _ := FromIETFName("es-ES")
+	english, _ := FromIETFName("en")
+
+	// Without a dub, dub_first keeps the flagged audio and the VOS strategies look for the original
+	for name, want := range map[string][]int{
+		StrategyDubFirst:      {1, 2, 4},
+		StrategyLanguageChain: {1, 2, 4},
+		StrategyOriginalSubs:  {1, 3, 4},
+		StrategyOriginalOnly:  {1, 3},
+	} {
+		strategy, err := NewDefaultTrackStrategy(name, spanish, english, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cont.GetDefaultTracks(strategy); !slices.Equal(got, want) {
+			t.Errorf("%s: expected default tracks %v, got %v", name, want, got)
+		}
+	}
+}
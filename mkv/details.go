@@ -0,0 +1,110 @@
+package mkv
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var chromaFormats = map[int]string{0: "4:0:0", 1: "4:2:0", 2: "4:2:2", 3: "4:4:4"}
+
+var hevcProfileNames = map[int]string{
+	1: "Main",
+	2: "Main 10",
+	3: "Main Still Picture",
+	4: "Range Extensions",
+	5: "High Throughput",
+	9: "Screen Content Coding",
+}
+
+// CodecDetails is what the codec private data of a track tells about its stream. Fields the codec
+// doesn't signal are left empty.
+type CodecDetails struct {
+	Profile      string // e.g. "High 10", "Main 10", "LC" or "HE-AAC"
+	Level        string // e.g. "4.1"
+	ChromaFormat string // e.g. "4:2:0"
+	BitDepth     int
+	Channels     int // Channel count of the stream, which may differ from the container value
+	SampleRate   int
+	// Opus channel mapping: 0 for mono and stereo, 1 for the Vorbis surround layouts
+	ChannelMappingFamily int
+}
+
+// CodecDetails decodes the codec private data of AVC, HEVC, AAC, Opus and FLAC tracks.
+func (tp *TrackProperties) CodecDetails() (CodecDetails, error) {
+	var details CodecDetails
+	switch tp.Codec().Name {
+	case "H264":
+		sps, err := parseAVCConfig(tp.CodecPrivateData)
+		if err != nil {
+			return details, err
+		}
+		details.Profile = sps.profileName()
+		details.Level = sps.levelName()
+		details.ChromaFormat = chromaFormats[sps.ChromaFormat]
+		details.BitDepth = sps.BitDepthLuma
+
+	case "HEVC":
+		config, err := parseHEVCConfig(tp.CodecPrivateData)
+		if err != nil {
+			return details, err
+		}
+		details.Profile = hevcProfileNames[config.Profile]
+		if details.Profile == "" {
+			details.Profile = fmt.Sprintf("Profile %d", config.Profile)
+		}
+		// level_idc is 30 times the level number
+		details.Level = strconv.FormatFloat(float64(config.Level)/30, 'f', -1, 64)
+		details.ChromaFormat = chromaFormats[config.ChromaFormat]
+		details.BitDepth = config.BitDepthLuma
+		// Old muxers leave the record fields empty, the SPS has them too
+		for _, nal := range config.NALUnits[hevcNALSPS] {
+			if sps, err := parseHEVCSPS(nal); err == nil {
+				details.ChromaFormat = chromaFormats[sps.ChromaFormat]
+				details.BitDepth = sps.BitDepthLuma
+				break
+			}
+		}
+
+	case "AAC":
+		objectType := aacProfileFromCodecID(tp.CodecID)
+		if len(tp.CodecPrivateData) > 0 {
+			config, err := parseAACConfig(tp.CodecPrivateData)
+			if err != nil {
+				return details, err
+			}
+			objectType = config.ObjectType
+			details.Channels = aacChannels[config.ChannelConfig]
+			details.SampleRate = config.SampleRate
+			if objectType == aacSBR || objectType == aacPS {
+				details.SampleRate *= 2
+			}
+			if objectType == aacPS && details.Channels == 1 {
+				// Parametric stereo decodes a mono core to stereo
+				details.Channels = 2
+			}
+		}
+		details.Profile = aacProfileNames[objectType]
+
+	case "Opus":
+		header, err := parseOpusHeader(tp.CodecPrivateData)
+		if err != nil {
+			return details, err
+		}
+		details.Channels = header.Channels
+		details.SampleRate = header.SampleRate
+		details.ChannelMappingFamily = header.MappingFamily
+
+	case "FLAC":
+		info, err := parseFLACStreamInfo(tp.CodecPrivateData)
+		if err != nil {
+			return details, err
+		}
+		details.Channels = info.Channels
+		details.SampleRate = info.SampleRate
+		details.BitDepth = info.BitDepth
+
+	default:
+		return details, fmt.Errorf("no codec details for %s", tp.CodecID)
+	}
+	return details, nil
+}
@@ -0,0 +1,107 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+func buildAVCConfig(profile, level int, bitDepth uint64) []byte {
+	bw := &bitWriter{}
+	bw.u(uint64(profile), 8)
+	bw.u(0, 8)
+	bw.u(uint64(level), 8)
+	bw.ue(0) // seq_parameter_set_id
+	if profile >= 100 {
+		bw.ue(1) // 4:2:0
+		bw.ue(bitDepth - 8)
+		bw.ue(bitDepth - 8)
+	}
+	bw.ue(0)
+	sps := append([]byte{0x67}, bw.nal(0)[2:]...)
+
+	config := []byte{1, byte(profile), 0, byte(level), 0xFF, 0xE1}
+	config = binary.BigEndian.AppendUint16(config, uint16(len(sps)))
+	return append(config, sps...)
+}
+
+// buildAAC encodes an AudioSpecificConfig from its fields, given as value and bit count pairs.
+func buildAAC(fields ...uint64) []byte {
+	bw := &bitWriter{}
+	for i := 0; i < len(fields); i += 2 {
+		bw.u(fields[i], int(fields[i+1]))
+	}
+	return bw.data
+}
+
+func TestCodecDetails(t *testing.T) {
+	opus := []byte("OpusHead\x01\x06\x38\x01\x80\xbb\x00\x00\x00\x00\x01\x04\x02\x00\x04\x01\x02\x03\x05")
+	flac := append([]byte("fLaC\x80\x00\x00\x22"), make([]byte, 34)...)
+	// 48 kHz, 6 channels, 24 bits
+	copy(flac[18:], []byte{0x0B, 0xB8, 0x0B, 0x70})
+
+	for name, test := range map[string]struct {
+		properties TrackProperties
+		details    CodecDetails
+	}{
+		"avc high 10": {
+			TrackProperties{CodecID: "V_MPEG4/ISO/AVC", CodecPrivateData: buildAVCConfig(110, 51, 10)},
+			CodecDetails{Profile: "High 10", Level: "5.1", ChromaFormat: "4:2:0", BitDepth: 10},
+		},
+		"avc main": {
+			TrackProperties{CodecID: "V_MPEG4/ISO/AVC", CodecPrivateData: buildAVCConfig(77, 40, 8)},
+			CodecDetails{Profile: "Main", Level: "4.0", ChromaFormat: "4:2:0", BitDepth: 8},
+		},
+		"hevc main 10": {
+			TrackProperties{CodecID: "V_MPEGH/ISO/HEVC", CodecPrivateData: buildHEVCConfig(buildHEVCSPS(transferPQ))},
+			CodecDetails{Profile: "Main 10", Level: "5.1", ChromaFormat: "4:2:0", BitDepth: 10},
+		},
+		"aac lc": {
+			TrackProperties{CodecID: "A_AAC", CodecPrivateData: []byte{0x11, 0x90}},
+			CodecDetails{Profile: "LC", Channels: 2, SampleRate: 48000},
+		},
+		"he-aac explicit": {
+			TrackProperties{CodecID: "A_AAC", CodecPrivateData: buildAAC(aacSBR, 5, 6, 4, 2, 4, 3, 4, aacLC, 5)},
+			CodecDetails{Profile: "HE-AAC", Channels: 2, SampleRate: 48000},
+		},
+		"he-aacv2 implicit": {
+			TrackProperties{CodecID: "A_AAC", CodecPrivateData: buildAAC(aacLC, 5, 6, 4, 1, 4, 0, 3, 0x2B7, 11, aacSBR, 5, 1, 1, 3, 4, 0x548, 11, 1, 1, 0, 3)},
+			CodecDetails{Profile: "HE-AACv2", Channels: 2, SampleRate: 48000},
+		},
+		"aac legacy codec id": {
+			TrackProperties{CodecID: "A_AAC/MPEG4/LC/SBR"},
+			CodecDetails{Profile: "HE-AAC"},
+		},
+		"opus 5.1": {
+			TrackProperties{CodecID: "A_OPUS", CodecPrivateData: opus},
+			CodecDetails{Channels: 6, SampleRate: 48000, ChannelMappingFamily: 1},
+		},
+		"flac": {
+			TrackProperties{CodecID: "A_FLAC", CodecPrivateData: flac},
+			CodecDetails{Channels: 6, SampleRate: 48000, BitDepth: 24},
+		},
+	} {
+		details, err := test.properties.CodecDetails()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if details != test.details {
+			t.Errorf("%s: expected %+v, got %+v", name, test.details, details)
+		}
+	}
+
+	if _, err := (&TrackProperties{CodecID: "V_MPEGH/ISO/HEVC", CodecPrivateData: []byte{1, 2}}).CodecDetails(); err == nil {
+		t.Error("expected an error for truncated codec private data")
+	}
+}
+
+func TestNamingMetadataCodecDetails(t *testing.T) {
+	video := Track{Type: "video", Properties: TrackProperties{CodecID: "V_MPEG4/ISO/AVC", CodecPrivateData: buildAVCConfig(110, 41, 10)}}
+	if metadata := video.NamingMetadata(); !slices.Equal(metadata, []string{"H264", "10bit"}) {
+		t.Errorf("unexpected video metadata %v", metadata)
+	}
+
+	audio := Track{Type: "audio", Properties: TrackProperties{CodecID: "A_AAC", CodecPrivateData: buildAAC(aacPS, 5, 6, 4, 1, 4, 3, 4, aacLC, 5)}}
+	if metadata := audio.NamingMetadata(); !slices.Equal(metadata, []string{"HE-AACv2 2.0"}) {
+		t.Errorf("unexpected audio metadata %v", metadata)
+	}
+}
@@ -3,10 +3,11 @@ package mkv
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path"
 	"slices"
 	"strings"
+	"time"
+	"videorepack/tools"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -18,13 +19,8 @@ func sortedExtractedTracks(tracks []ExtractedTrack) []ExtractedTrack {
 	slices.SortFunc(sorted, func(a, b ExtractedTrack) int {
 		if a.Info.Type != b.Info.Type {
 			// Order by type: video, audio, subtitles, others
-			typeOrder := map[string]int{
-				"video":     0,
-				"audio":     1,
-				"subtitles": 2,
-			}
-			aOrder, aOk := typeOrder[a.Info.Type]
-			bOrder, bOk := typeOrder[b.Info.Type]
+			aOrder, aOk := trackTypeOrder[a.Info.Type]
+			bOrder, bOk := trackTypeOrder[b.Info.Type]
 			if !aOk {
 				aOrder = 99
 			}
@@ -33,6 +29,15 @@ func sortedExtractedTracks(tracks []ExtractedTrack) []ExtractedTrack {
 			}
 			return aOrder - bOrder
 		} else {
+			if a.Info.Properties.FlagCommentary != b.Info.Properties.FlagCommentary {
+				// Commentary tracks last
+				if a.Info.Properties.FlagCommentary {
+					return 1
+				} else {
+					return -1
+				}
+			}
+
 			if a.Info.Properties.LanguageIETF != b.Info.Properties.LanguageIETF {
 				// Sort by language
 				return strings.Compare(a.Info.Properties.LanguageIETF.String(), b.Info.Properties.LanguageIETF.String())
@@ -63,7 +68,7 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 		log.Debugf("Empty output path, extracting to temp dir")
 		output, err = os.MkdirTemp(os.TempDir(), "videorepack_")
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("error creating temp dir: %v", err)
 		}
 		defer os.Remove(output)
 	} else {
@@ -80,6 +85,11 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 
 	var tracks []ExtractedTrack
 	for _, track := range identity.Tracks {
+		if codec := track.Properties.Codec(); !codec.Extractable {
+			log.Warnf("Skipping track %d: mkvextract can't extract %s tracks (%s)", track.ID, codec.Name, track.Properties.CodecID)
+			continue
+		}
+
 		outputPath := path.Join(output, fmt.Sprintf("track_%d.%s", track.ID,
 			track.Properties.FileExtension()))
 
@@ -88,9 +98,15 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 			timeMapPath = path.Join(output, fmt.Sprintf("track_%d_timemap.txt", track.ID))
 		}
 
+		var extraFiles []string
+		if track.Properties.CodecID == "S_VOBSUB" {
+			extraFiles = append(extraFiles, strings.TrimSuffix(outputPath, ".idx")+".sub")
+		}
+
 		tracks = append(tracks, ExtractedTrack{
 			Info:        track,
 			FilePath:    outputPath,
+			ExtraFiles:  extraFiles,
 			TimeMapPath: timeMapPath,
 			Operations:  TrackOperations{},
 		})
@@ -98,23 +114,19 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 
 	// Extraer cada pista
 	for _, t := range tracks {
-		cmd := exec.Command("mkvextract", "tracks", input,
-			fmt.Sprintf("%d:%s", t.Info.ID, t.FilePath))
-
 		log.Tracef("Extracting track %d (type: %s) to %s", t.Info.ID, t.Info.Type, t.FilePath)
 
-		if _, err := cmd.Output(); err != nil {
+		if _, err := tools.Run(tools.MKVExtract, "tracks", input,
+			fmt.Sprintf("%d:%s", t.Info.ID, t.FilePath)); err != nil {
 			return nil, fmt.Errorf("mkvextract error: %v", err)
 		}
 
 		if len(t.TimeMapPath) > 0 {
 			// Extraer timecodes si es pista de video
-			cmdTimeMap := exec.Command("mkvextract", "timecodes_v2", input,
-				fmt.Sprintf("%d:%s", t.Info.ID, t.TimeMapPath))
-
 			log.Tracef("Extracting timecodes for track %d to %s", t.Info.ID, t.TimeMapPath)
 
-			if _, err := cmdTimeMap.Output(); err != nil {
+			if _, err := tools.Run(tools.MKVExtract, "timecodes_v2", input,
+				fmt.Sprintf("%d:%s", t.Info.ID, t.TimeMapPath)); err != nil {
 				return nil, fmt.Errorf("mkvextract timecodes error: %v", err)
 			}
 		}
@@ -124,10 +136,8 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 	chaptersOut := ""
 	if len(identity.Chapters) > 0 {
 		chaptersOut = path.Join(output, "chapters.xml")
-		cmd := exec.Command("mkvextract", input, "chapters", chaptersOut)
-
 		log.Tracef("Extracting chapters to %s", chaptersOut)
-		if _, err := cmd.Output(); err != nil {
+		if _, err := tools.Run(tools.MKVExtract, input, "chapters", chaptersOut); err != nil {
 			return nil, fmt.Errorf("mkvextract chapters error: %v", err)
 		}
 	}
@@ -137,11 +147,9 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 	if len(identity.Attachments) > 0 {
 		for _, attachment := range identity.Attachments {
 			attachmentOut := path.Join(output, fmt.Sprintf("attachment_%d_%s", attachment.ID, attachment.FileName))
-			cmd := exec.Command("mkvextract", input, "attachments",
-				fmt.Sprintf("%d:%s", attachment.ID, attachmentOut))
-
 			log.Tracef("Extracting attachment %d to %s", attachment.ID, attachmentOut)
-			if _, err := cmd.Output(); err != nil {
+			if _, err := tools.Run(tools.MKVExtract, input, "attachments",
+				fmt.Sprintf("%d:%s", attachment.ID, attachmentOut)); err != nil {
 				return nil, fmt.Errorf("mkvextract attachment error: %v", err)
 			}
 			attachments = append(attachments, ExtractedAttachment{
@@ -152,7 +160,9 @@ func ExtractAll(input string, output string) (*ExtractedContainer, error) {
 	}
 
 	return &ExtractedContainer{
-		Tracks:   sortedExtractedTracks(tracks),
-		Chapters: chaptersOut,
+		Tracks:      sortedExtractedTracks(tracks),
+		Attachments: attachments,
+		Chapters:    chaptersOut,
+		Duration:    time.Duration(identity.Container.Properties.Duration),
 	}, nil
 }
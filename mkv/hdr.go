@@ -0,0 +1,172 @@
+package mkv
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+// HDRFormat is a dynamic range format of a video track.
+type HDRFormat string
+
+const (
+	SDR         HDRFormat = "SDR"
+	HDR10       HDRFormat = "HDR10"
+	HDR10Plus   HDRFormat = "HDR10+"
+	HLG         HDRFormat = "HLG"
+	DolbyVision HDRFormat = "DV"
+)
+
+// Transfer characteristics of ITU-T H.273 used by HDR formats
+const (
+	transferPQ  = 16 // SMPTE ST 2084
+	transferHLG = 18 // ARIB STD-B67
+)
+
+// DolbyVisionConfig is the Dolby Vision decoder configuration record of a track.
+type DolbyVisionConfig struct {
+	Profile       int
+	Level         int
+	RPU           bool // The track carries the dynamic metadata
+	EL            bool // The track carries an enhancement layer
+	BL            bool // The track carries a base layer
+	Compatibility int  // bl_signal_compatibility_id: 1 HDR10, 2 SDR, 4 HLG, 0 none
+}
+
+// HDRInfo describes the dynamic range of a video track.
+type HDRInfo struct {
+	Transfer         int  // Transfer characteristics (ITU-T H.273), 2 when unspecified
+	MasteringDisplay bool // Static mastering display metadata is present
+	HDR10Plus        bool // ST 2094-40 dynamic metadata is present
+	DolbyVision      *DolbyVisionConfig
+}
+
+// base returns the format of the base layer, which players without Dolby Vision support show.
+func (h HDRInfo) base() HDRFormat {
+	transfer := h.Transfer
+	if dv := h.DolbyVision; dv != nil && (transfer == 0 || transfer == 2) {
+		switch dv.Compatibility {
+		case 1, 6:
+			transfer = transferPQ
+		case 4:
+			transfer = transferHLG
+		}
+	}
+
+	switch {
+	case transfer == transferPQ && h.HDR10Plus:
+		return HDR10Plus
+	case transfer == transferPQ:
+		return HDR10
+	case transfer == transferHLG:
+		return HLG
+	case h.DolbyVision != nil && h.DolbyVision.Compatibility == 0:
+		// Profile 5 has no cross-compatible base layer
+		return ""
+	}
+	return SDR
+}
+
+// Dynamic reports whether the track carries dynamic metadata, Dolby Vision or HDR10+, which is lost
+// when the video is re-encoded.
+func (h HDRInfo) Dynamic() bool {
+	return h.DolbyVision != nil || h.HDR10Plus
+}
+
+// Formats returns the dynamic range formats of the track: Dolby Vision first, then the base layer
+// format. SDR tracks return SDR.
+func (h HDRInfo) Formats() []HDRFormat {
+	var formats []HDRFormat
+	if h.DolbyVision != nil {
+		formats = append(formats, DolbyVision)
+	}
+	if base := h.base(); base != "" && (base != SDR || h.DolbyVision == nil) {
+		formats = append(formats, base)
+	}
+	return formats
+}
+
+// Tags returns the HDR formats as tags for file names, e.g. "DV P8" and "HDR10". SDR tracks have
+// no tags.
+func (h HDRInfo) Tags() []string {
+	var tags []string
+	for _, f := range h.Formats() {
+		switch f {
+		case SDR:
+		case DolbyVision:
+			tags = append(tags, fmt.Sprintf("DV P%d", h.DolbyVision.Profile))
+		default:
+			tags = append(tags, string(f))
+		}
+	}
+	return tags
+}
+
+// HasOnly reports whether every format of the track is one of the given formats.
+func (h HDRInfo) HasOnly(formats []HDRFormat) bool {
+	for _, f := range h.Formats() {
+		if !slices.Contains(formats, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// HDR detects the dynamic range formats of a video track from its colour properties and its codec
+// private data: the VUI and SEI messages of the HEVC parameter sets and the Dolby Vision
+// configuration record.
+func (t *Track) HDR() HDRInfo {
+	p := &t.Properties
+	info := HDRInfo{
+		Transfer:         p.ColorTransferCharacteristics,
+		MasteringDisplay: p.MaxLuminance > 0,
+	}
+	if t.Type != "video" {
+		return info
+	}
+
+	if config, err := parseHEVCConfig(p.CodecPrivateData); p.Codec().Name == "HEVC" && err == nil {
+		for _, nal := range config.NALUnits[hevcNALSPS] {
+			if sps, err := parseHEVCSPS(nal); err == nil && (info.Transfer == 0 || info.Transfer == 2) {
+				info.Transfer = sps.TransferCharacteristics
+			}
+		}
+		for _, nal := range append(config.NALUnits[hevcNALPrefixSEI], config.NALUnits[hevcNALSuffixSEI]...) {
+			for _, sei := range parseSEI(nal) {
+				switch {
+				case sei.Type == seiMasteringDisplay:
+					info.MasteringDisplay = true
+				case sei.Type == seiUserDataRegistered && isHDR10Plus(sei.Payload):
+					info.HDR10Plus = true
+				}
+			}
+		}
+	}
+
+	info.DolbyVision = findDolbyVisionConfig(p.CodecPrivateData)
+	return info
+}
+
+// findDolbyVisionConfig looks for a dvcC, dvvC or dvwC box in the codec private data and parses
+// the configuration record it holds.
+func findDolbyVisionConfig(data []byte) *DolbyVisionConfig {
+	for _, tag := range []string{"dvcC", "dvvC", "dvwC"} {
+		pos := bytes.Index(data, []byte(tag))
+		if pos == -1 || pos+4+5 > len(data) {
+			continue
+		}
+		record := data[pos+4:]
+		if record[0] == 0 || record[0] > 3 { // dv_version_major
+			continue
+		}
+		return &DolbyVisionConfig{
+			Profile:       int(record[2] >> 1),
+			Level:         int(record[2]&1)<<5 | int(record[3]>>3),
+			RPU:           record[3]&4 != 0,
+			EL:            record[3]&2 != 0,
+			BL:            record[3]&1 != 0,
+			Compatibility: int(record[4] >> 4),
+		}
+	}
+	return nil
+}
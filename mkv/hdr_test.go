@@ -0,0 +1,210 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// bitWriter encodes codec headers for the tests.
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (bw *bitWriter) u(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if bw.bits%8 == 0 {
+			bw.data = append(bw.data, 0)
+		}
+		bw.data[len(bw.data)-1] |= byte(v>>i&1) << (7 - bw.bits%8)
+		bw.bits++
+	}
+}
+
+func (bw *bitWriter) flag(b bool) {
+	if b {
+		bw.u(1, 1)
+	} else {
+		bw.u(0, 1)
+	}
+}
+
+func (bw *bitWriter) ue(v uint64) {
+	n := 0
+	for (v+1)>>n > 1 {
+		n++
+	}
+	bw.u(0, n)
+	bw.u(v+1, n+1)
+}
+
+// nal ends the RBSP and adds the NAL unit header and the emulation prevention bytes.
+func (bw *bitWriter) nal(nalType int) []byte {
+	bw.u(1, 1)
+	nal := []byte{byte(nalType << 1), 1}
+	zeros := 0
+	for _, b := range bw.data {
+		if zeros >= 2 && b <= 3 {
+			nal = append(nal, 3)
+			zeros = 0
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		nal = append(nal, b)
+	}
+	return nal
+}
+
+func buildHEVCSPS(transfer uint64) []byte {
+	bw := &bitWriter{}
+	bw.u(0, 4) // sps_video_parameter_set_id
+	bw.u(0, 3) // sps_max_sub_layers_minus1
+	bw.u(1, 1)
+	bw.u(0, 3)           // profile space and tier
+	bw.u(2, 5)           // Main 10
+	bw.u(0x20000000, 32) // compatibility flags
+	bw.u(0, 4+43+1)      // source and constraint flags
+	bw.u(153, 8)         // level 5.1
+	bw.ue(0)             // sps_seq_parameter_set_id
+	bw.ue(1)             // 4:2:0
+	bw.ue(3840)          // width
+	bw.ue(2160)          // height
+	bw.flag(false)       // conformance_window_flag
+	bw.ue(2)             // 10 bits luma
+	bw.ue(2)             // 10 bits chroma
+	bw.ue(4)             // log2_max_pic_order_cnt_lsb_minus4
+	bw.flag(true)        // sps_sub_layer_ordering_info_present_flag
+	for _, v := range []uint64{4, 0, 0, 0, 3, 0, 3, 0, 0} {
+		bw.ue(v)
+	}
+
+	bw.flag(true) // scaling_list_enabled_flag
+	bw.flag(true) // sps_scaling_list_data_present_flag
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		step := 1
+		if sizeID == 3 {
+			step = 3
+		}
+		for matrixID := 0; matrixID < 6; matrixID += step {
+			explicit := sizeID == 2 && matrixID == 0
+			bw.flag(explicit)
+			if !explicit {
+				bw.ue(0)
+				continue
+			}
+			for i := 0; i < 1+64; i++ {
+				bw.ue(1) // se(-1)
+			}
+		}
+	}
+	bw.u(3, 2)     // amp and SAO
+	bw.flag(false) // pcm_enabled_flag
+
+	bw.ue(2) // num_short_term_ref_pic_sets
+	bw.ue(2)
+	bw.ue(1)
+	for i := 0; i < 3; i++ {
+		bw.ue(0)
+		bw.flag(true)
+	}
+	bw.flag(true) // inter_ref_pic_set_prediction_flag
+	bw.flag(false)
+	bw.ue(0)
+	for _, used := range []bool{true, false, false, true} {
+		bw.flag(used)
+		if !used {
+			bw.flag(false)
+		}
+	}
+	bw.flag(true) // long_term_ref_pics_present_flag
+	bw.ue(1)
+	bw.u(0, 8)
+	bw.flag(true)
+	bw.u(3, 2) // temporal MVP and strong intra smoothing
+
+	bw.flag(true) // vui_parameters_present_flag
+	bw.flag(true) // aspect_ratio_info_present_flag
+	bw.u(255, 8)
+	bw.u(1, 16)
+	bw.u(1, 16)
+	bw.flag(false) // overscan_info_present_flag
+	bw.flag(true)  // video_signal_type_present_flag
+	bw.u(5, 3)
+	bw.flag(false)
+	bw.flag(true) // colour_description_present_flag
+	bw.u(9, 8)
+	bw.u(transfer, 8)
+	bw.u(9, 8)
+	return bw.nal(hevcNALSPS)
+}
+
+func buildHEVCConfig(nals ...[]byte) []byte {
+	config := []byte{1, 2, 0x20, 0, 0, 0, 0, 0, 0, 0, 0, 0, 153, 0xF0, 0, 0xFC, 0xFD, 0xFA, 0xFA, 0, 0, 0x0F, byte(len(nals))}
+	for _, nal := range nals {
+		config = append(config, 0x80|nal[0]>>1)
+		config = binary.BigEndian.AppendUint16(config, 1)
+		config = binary.BigEndian.AppendUint16(config, uint16(len(nal)))
+		config = append(config, nal...)
+	}
+	return config
+}
+
+func TestParseHEVCSPS(t *testing.T) {
+	sps, err := parseHEVCSPS(buildHEVCSPS(transferHLG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sps.Profile != 2 || sps.Level != 153 || sps.Width != 3840 || sps.Height != 2160 || sps.BitDepthLuma != 10 || sps.ChromaFormat != 1 {
+		t.Errorf("unexpected SPS %+v", sps)
+	}
+	if sps.ColourPrimaries != 9 || sps.TransferCharacteristics != transferHLG || sps.MatrixCoefficients != 9 {
+		t.Errorf("unexpected colour description %+v", sps)
+	}
+}
+
+func TestHDR(t *testing.T) {
+	hdr10PlusSEI := []byte{39 << 1, 1, seiUserDataRegistered, 7, 0xB5, 0, 0x3C, 0, 1, 4, 1, 0x80}
+	dolbyVision := []byte{'d', 'v', 'c', 'C', 1, 0, 8<<1 | 0, 6<<3 | 5, 1 << 4}
+
+	for name, test := range map[string]struct {
+		track   Track
+		formats []HDRFormat
+		tags    []string
+	}{
+		"sdr": {
+			Track{Type: "video", Properties: TrackProperties{CodecID: "V_MPEG4/ISO/AVC"}},
+			[]HDRFormat{SDR}, nil,
+		},
+		"colour properties": {
+			Track{Type: "video", Properties: TrackProperties{CodecID: "V_AV1", VideoTrackProperties: VideoTrackProperties{ColorTransferCharacteristics: transferPQ, MaxLuminance: 1000}}},
+			[]HDRFormat{HDR10}, []string{"HDR10"},
+		},
+		"hlg vui": {
+			Track{Type: "video", Properties: TrackProperties{CodecID: "V_MPEGH/ISO/HEVC", CodecPrivateData: buildHEVCConfig(buildHEVCSPS(transferHLG))}},
+			[]HDRFormat{HLG}, []string{"HLG"},
+		},
+		"hdr10+": {
+			Track{Type: "video", Properties: TrackProperties{CodecID: "V_MPEGH/ISO/HEVC", CodecPrivateData: buildHEVCConfig(buildHEVCSPS(transferPQ), hdr10PlusSEI)}},
+			[]HDRFormat{HDR10Plus}, []string{"HDR10+"},
+		},
+		"dolby vision": {
+			Track{Type: "video", Properties: TrackProperties{CodecID: "V_MPEGH/ISO/HEVC", CodecPrivateData: append(buildHEVCConfig(buildHEVCSPS(2)), dolbyVision...)}},
+			[]HDRFormat{DolbyVision, HDR10}, []string{"DV P8", "HDR10"},
+		},
+	} {
+		hdr := test.track.HDR()
+		if !slices.Equal(hdr.Formats(), test.formats) || !slices.Equal(hdr.Tags(), test.tags) {
+			t.Errorf("%s: expected %v %v, got %v %v", name, test.formats, test.tags, hdr.Formats(), hdr.Tags())
+		}
+		if name == "dolby vision" && (hdr.DolbyVision.Level != 6 || !hdr.DolbyVision.RPU || !hdr.DolbyVision.BL || hdr.DolbyVision.EL) {
+			t.Errorf("unexpected Dolby Vision configuration %+v", hdr.DolbyVision)
+		}
+		if hdr.HasOnly([]HDRFormat{SDR, HDR10}) != (name == "sdr" || name == "colour properties") {
+			t.Errorf("%s: unexpected HasOnly result", name)
+		}
+	}
+}
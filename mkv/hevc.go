@@ -0,0 +1,311 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// HEVC NAL unit types found in the decoder configuration record
+const (
+	hevcNALVPS       = 32
+	hevcNALSPS       = 33
+	hevcNALPPS       = 34
+	hevcNALPrefixSEI = 39
+	hevcNALSuffixSEI = 40
+)
+
+var errTruncatedConfig = errors.New("truncated codec configuration")
+
+// hevcConfig is an HEVCDecoderConfigurationRecord, the codec private data of HEVC tracks.
+type hevcConfig struct {
+	Profile        int
+	Tier           int
+	Level          int
+	ChromaFormat   int
+	BitDepthLuma   int
+	BitDepthChroma int
+	NALUnits       map[int][][]byte // Parameter sets and SEI messages by NAL unit type
+}
+
+func parseHEVCConfig(data []byte) (*hevcConfig, error) {
+	if len(data) < 23 {
+		return nil, errTruncatedConfig
+	}
+	if data[0] != 1 {
+		return nil, fmt.Errorf("unsupported HEVC configuration version %d", data[0])
+	}
+
+	config := &hevcConfig{
+		Profile:        int(data[1] & 0x1F),
+		Tier:           int(data[1] >> 5 & 1),
+		Level:          int(data[12]),
+		ChromaFormat:   int(data[16] & 3),
+		BitDepthLuma:   int(data[17]&7) + 8,
+		BitDepthChroma: int(data[18]&7) + 8,
+		NALUnits:       make(map[int][][]byte),
+	}
+
+	pos := 23
+	for i := 0; i < int(data[22]); i++ {
+		if pos+3 > len(data) {
+			return nil, errTruncatedConfig
+		}
+		nalType := int(data[pos] & 0x3F)
+		count := int(binary.BigEndian.Uint16(data[pos+1:]))
+		pos += 3
+		for j := 0; j < count; j++ {
+			if pos+2 > len(data) {
+				return nil, errTruncatedConfig
+			}
+			length := int(binary.BigEndian.Uint16(data[pos:]))
+			if pos+2+length > len(data) {
+				return nil, errTruncatedConfig
+			}
+			config.NALUnits[nalType] = append(config.NALUnits[nalType], data[pos+2:pos+2+length])
+			pos += 2 + length
+		}
+	}
+	return config, nil
+}
+
+// hevcSPS holds the fields of an HEVC sequence parameter set up to the video signal description.
+type hevcSPS struct {
+	Profile        int
+	Tier           int
+	Level          int
+	ChromaFormat   int
+	Width          int
+	Height         int
+	BitDepthLuma   int
+	BitDepthChroma int
+
+	// Video signal type of the VUI, 2 (unspecified) when absent
+	ColourPrimaries         int
+	TransferCharacteristics int
+	MatrixCoefficients      int
+	FullRange               bool
+}
+
+func parseHEVCSPS(nal []byte) (*hevcSPS, error) {
+	if len(nal) < 2 || int(nal[0]>>1&0x3F) != hevcNALSPS {
+		return nil, fmt.Errorf("not an HEVC SPS")
+	}
+	br := &bitReader{data: unescapeRBSP(nal[2:])}
+	sps := &hevcSPS{ColourPrimaries: 2, TransferCharacteristics: 2, MatrixCoefficients: 2}
+
+	br.skip(4) // sps_video_parameter_set_id
+	maxSubLayersMinus1 := int(br.u(3))
+	br.skip(1) // sps_temporal_id_nesting_flag
+
+	// profile_tier_level
+	br.skip(2) // general_profile_space
+	sps.Tier = int(br.u(1))
+	sps.Profile = int(br.u(5))
+	br.skip(32 + 4 + 43 + 1)
+	sps.Level = int(br.u(8))
+	subLayerProfile := make([]bool, maxSubLayersMinus1)
+	subLayerLevel := make([]bool, maxSubLayersMinus1)
+	for i := 0; i < maxSubLayersMinus1; i++ {
+		subLayerProfile[i] = br.flag()
+		subLayerLevel[i] = br.flag()
+	}
+	if maxSubLayersMinus1 > 0 {
+		br.skip(2 * (8 - maxSubLayersMinus1))
+	}
+	for i := 0; i < maxSubLayersMinus1; i++ {
+		if subLayerProfile[i] {
+			br.skip(88)
+		}
+		if subLayerLevel[i] {
+			br.skip(8)
+		}
+	}
+
+	br.ue() // sps_seq_parameter_set_id
+	sps.ChromaFormat = int(br.ue())
+	if sps.ChromaFormat == 3 {
+		br.skip(1) // separate_colour_plane_flag
+	}
+	sps.Width = int(br.ue())
+	sps.Height = int(br.ue())
+	if br.flag() { // conformance_window_flag
+		for i := 0; i < 4; i++ {
+			br.ue()
+		}
+	}
+	sps.BitDepthLuma = int(br.ue()) + 8
+	sps.BitDepthChroma = int(br.ue()) + 8
+	log2MaxPocLsb := int(br.ue()) + 4
+
+	first := maxSubLayersMinus1
+	if br.flag() { // sps_sub_layer_ordering_info_present_flag
+		first = 0
+	}
+	for i := first; i <= maxSubLayersMinus1; i++ {
+		br.ue()
+		br.ue()
+		br.ue()
+	}
+	for i := 0; i < 6; i++ {
+		br.ue() // coding and transform block sizes and hierarchy depths
+	}
+
+	if br.flag() && br.flag() { // scaling_list_enabled_flag, sps_scaling_list_data_present_flag
+		skipScalingListData(br)
+	}
+	// amp_enabled_flag, sample_adaptive_offset_enabled_flag
+	br.skip(2)
+	if br.flag() { // pcm_enabled_flag
+		br.skip(8)
+		br.ue()
+		br.ue()
+		br.skip(1)
+	}
+
+	numSets := int(br.ue())
+	if numSets > 64 {
+		return nil, fmt.Errorf("invalid HEVC SPS: %d short-term reference picture sets", numSets)
+	}
+	numDeltaPocs := make([]int, numSets)
+	for i := 0; i < numSets; i++ {
+		numDeltaPocs[i] = skipShortTermRefPicSet(br, i, numDeltaPocs)
+	}
+	if br.flag() { // long_term_ref_pics_present_flag
+		for i := br.ue(); i > 0 && br.err == nil; i-- {
+			br.skip(log2MaxPocLsb + 1)
+		}
+	}
+	br.skip(2) // sps_temporal_mvp_enabled_flag, strong_intra_smoothing_enabled_flag
+
+	if br.flag() { // vui_parameters_present_flag
+		if br.flag() && br.u(8) == 255 { // aspect_ratio_info_present_flag, extended SAR
+			br.skip(32)
+		}
+		if br.flag() { // overscan_info_present_flag
+			br.skip(1)
+		}
+		if br.flag() { // video_signal_type_present_flag
+			br.skip(3) // video_format
+			sps.FullRange = br.flag()
+			if br.flag() { // colour_description_present_flag
+				sps.ColourPrimaries = int(br.u(8))
+				sps.TransferCharacteristics = int(br.u(8))
+				sps.MatrixCoefficients = int(br.u(8))
+			}
+		}
+	}
+
+	if br.err != nil {
+		return nil, fmt.Errorf("invalid HEVC SPS: %v", br.err)
+	}
+	return sps, nil
+}
+
+func skipScalingListData(br *bitReader) {
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		step := 1
+		if sizeID == 3 {
+			step = 3
+		}
+		for matrixID := 0; matrixID < 6; matrixID += step {
+			if !br.flag() { // scaling_list_pred_mode_flag
+				br.ue()
+				continue
+			}
+			coefs := min(64, 1<<(4+sizeID<<1))
+			if sizeID > 1 {
+				br.se()
+			}
+			for i := 0; i < coefs; i++ {
+				br.se()
+			}
+		}
+	}
+}
+
+// skipShortTermRefPicSet reads the st_ref_pic_set(index) of an SPS and returns its number of
+// delta POCs, which later sets predicted from it need.
+func skipShortTermRefPicSet(br *bitReader, index int, numDeltaPocs []int) int {
+	if index > 0 && br.flag() { // inter_ref_pic_set_prediction_flag
+		br.skip(1) // delta_rps_sign
+		br.ue()    // abs_delta_rps_minus1
+		count := 0
+		for j := 0; j <= numDeltaPocs[index-1]; j++ {
+			// use_delta_flag is only present when used_by_curr_pic_flag is not set, and is 1 otherwise
+			if br.flag() || br.flag() {
+				count++
+			}
+		}
+		return count
+	}
+
+	negative := int(br.ue())
+	positive := int(br.ue())
+	if negative > 16 || positive > 16 {
+		br.err = fmt.Errorf("invalid reference picture set")
+		return 0
+	}
+	for i := 0; i < negative+positive; i++ {
+		br.ue()    // delta_poc_minus1
+		br.skip(1) // used_by_curr_pic_flag
+	}
+	return negative + positive
+}
+
+// SEI payload types
+const (
+	seiUserDataRegistered = 4
+	seiMasteringDisplay   = 137
+	seiContentLightLevel  = 144
+)
+
+type seiMessage struct {
+	Type    int
+	Payload []byte
+}
+
+// parseSEI returns the messages of an HEVC SEI NAL unit.
+func parseSEI(nal []byte) []seiMessage {
+	if len(nal) < 2 {
+		return nil
+	}
+	data := unescapeRBSP(nal[2:])
+
+	var messages []seiMessage
+	for len(data) > 2 {
+		payloadType, payloadSize := 0, 0
+		for len(data) > 0 && data[0] == 0xFF {
+			payloadType += 255
+			data = data[1:]
+		}
+		if len(data) == 0 {
+			break
+		}
+		payloadType += int(data[0])
+		data = data[1:]
+		for len(data) > 0 && data[0] == 0xFF {
+			payloadSize += 255
+			data = data[1:]
+		}
+		if len(data) == 0 {
+			break
+		}
+		payloadSize += int(data[0])
+		data = data[1:]
+		if payloadSize > len(data) {
+			break
+		}
+		messages = append(messages, seiMessage{Type: payloadType, Payload: data[:payloadSize]})
+		data = data[payloadSize:]
+	}
+	return messages
+}
+
+// isHDR10Plus reports whether a registered user data SEI payload carries ST 2094-40 dynamic
+// metadata (country United States, provider Samsung, application 4).
+func isHDR10Plus(payload []byte) bool {
+	return len(payload) >= 6 && payload[0] == 0xB5 &&
+		binary.BigEndian.Uint16(payload[1:]) == 0x003C &&
+		binary.BigEndian.Uint16(payload[3:]) == 0x0001 && payload[5] == 4
+}
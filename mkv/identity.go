@@ -4,9 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"os/exec"
-	"strings"
 	"time"
+	"videorepack/tools"
 )
 
 type Chapter struct {
@@ -51,8 +50,7 @@ type Identity struct {
 }
 
 func Scan(input string) (*Identity, error) {
-	cmd := exec.Command("mkvmerge", "-J", input)
-	out, err := cmd.Output()
+	out, err := tools.Query(tools.MKVMerge, "-J", input)
 	if err != nil {
 		return nil, fmt.Errorf("mkvmerge -J error: %v", err)
 	}
@@ -68,22 +66,5 @@ func Scan(input string) (*Identity, error) {
 
 // patchIdentity applies optional fixes to the scanned Identity
 func patchIdentity(identity *Identity) {
-	patchLanguagesIdentity(identity)
-}
-
-func patchLanguagesIdentity(identity *Identity) {
-	for i, _ := range identity.Tracks {
-		tr := &identity.Tracks[i]
-		if strings.Index(tr.Properties.TrackName, "European Spanish") != -1 {
-			tr.Properties.LanguageIETF, _ = FromIETFName("es-ES")
-		} else if strings.Index(tr.Properties.TrackName, "Brazilian Portuguese") != -1 {
-			tr.Properties.LanguageIETF, _ = FromIETFName("pt-BR")
-		} else if strings.Index(tr.Properties.TrackName, "Arabic (Saudi Arabia)") != -1 {
-			tr.Properties.LanguageIETF, _ = FromIETFName("ar-SA")
-		} else if strings.Index(tr.Properties.TrackName, "Chinese (Taiwan)") != -1 {
-			tr.Properties.LanguageIETF, _ = FromIETFName("zh-TW")
-		} else if strings.Index(tr.Properties.TrackName, "Chinese (Simplified)") != -1 || strings.Index(tr.Properties.TrackName, "Chinese (Mainland China)") != -1 {
-			tr.Properties.LanguageIETF, _ = FromIETFName("zh-CN")
-		}
-	}
+	patchTracks(identity)
 }
@@ -0,0 +1,59 @@
+package mkv
+
+import (
+	"testing"
+	"videorepack/tools"
+)
+
+// useReplay replaces the tools executor for the duration of the test.
+func useReplay(t *testing.T) *tools.ReplayExecutor {
+	t.Helper()
+	replay := &tools.ReplayExecutor{}
+	previous := tools.CurrentExecutor()
+	tools.SetExecutor(replay)
+	t.Cleanup(func() { tools.SetExecutor(previous) })
+	return replay
+}
+
+const identityJSON = `{
+  "attachments": [],
+  "chapters": [{"num_entries": 6}],
+  "container": {"properties": {"duration": 1420000000000}, "recognized": true, "supported": true, "type": "Matroska"},
+  "tracks": [
+    {"id": 0, "type": "video", "codec": "HEVC/H.265/MPEG-H", "properties": {"codec_id": "V_MPEGH/ISO/HEVC", "language_ietf": "und", "pixel_dimensions": "1920x1080", "display_dimensions": "1920x1080", "tag_bps": "4000000"}},
+    {"id": 1, "type": "audio", "codec": "E-AC-3", "properties": {"codec_id": "A_EAC3", "language_ietf": "es", "track_name": "European Spanish", "audio_channels": 6, "tag_bps": 640000}},
+    {"id": 2, "type": "subtitles", "codec": "SubRip/SRT", "properties": {"codec_id": "S_TEXT/UTF8", "language_ietf": "zh", "track_name": "Chinese (Taiwan)", "text_subtitles": true}}
+  ]
+}`
+
+func TestScan(t *testing.T) {
+	replay := useReplay(t)
+	replay.On(tools.MKVMerge, []string{"-J", "episode.mkv"}, identityJSON, 0)
+
+	identity, err := Scan("episode.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(identity.Tracks) != 3 {
+		t.Fatalf("expected 3 tracks, got %d", len(identity.Tracks))
+	}
+	if bps := identity.Tracks[0].Properties.TagBps; bps != 4000000 {
+		t.Errorf("unexpected video bitrate %d", bps)
+	}
+	if lang := identity.Tracks[1].Properties.LanguageIETF.String(); lang != "es-ES" {
+		t.Errorf("audio language not patched from track name: %s", lang)
+	}
+	if lang := identity.Tracks[2].Properties.LanguageIETF.String(); lang != "zh-TW" {
+		t.Errorf("subtitle language not patched from track name: %s", lang)
+	}
+}
+
+func TestScanError(t *testing.T) {
+	replay := useReplay(t)
+	replay.On(tools.MKVMerge, []string{"-J", "broken.mkv"}, `{"errors": ["not a Matroska file"]}`, 2)
+
+	if _, err := Scan("broken.mkv"); err == nil {
+		t.Error("expected an error for a failed identification")
+	}
+}
@@ -53,3 +53,19 @@ func (li *LocaleInfo) RegionCode() string {
 	region, _ := li.Region()
 	return region.String()
 }
+
+// LangNameIn returns the name of the language in another language, e.g. "japonés" for ja in es.
+func (li *LocaleInfo) LangNameIn(in LocaleInfo) string {
+	base, _ := li.Base()
+	return display.Languages(in.Tag).Name(base)
+}
+
+// RegionNameIn returns the name of the region in another language, or an empty string when the
+// tag has no explicit region.
+func (li *LocaleInfo) RegionNameIn(in LocaleInfo) string {
+	region, confidence := li.Region()
+	if confidence != language.Exact {
+		return ""
+	}
+	return display.Regions(in.Tag).Name(region)
+}
@@ -3,8 +3,9 @@ package mkv
 import (
 	"errors"
 	"fmt"
-	"os/exec"
+	"strconv"
 	"strings"
+	"videorepack/tools"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -43,13 +44,41 @@ func Merge(output string, cont ExtractedContainer) error {
 			args = append(args, "--original-flag", fmt.Sprintf("%d:no", trackIndex))
 		}
 
-		if track.Operations.Delay != 0 {
+		if track.Info.Properties.FlagHearingImpaired {
+			args = append(args, "--hearing-impaired-flag", fmt.Sprintf("%d:yes", trackIndex))
+		} else {
+			args = append(args, "--hearing-impaired-flag", fmt.Sprintf("%d:no", trackIndex))
+		}
+
+		if track.Info.Properties.FlagVisualImpaired {
+			args = append(args, "--visual-impaired-flag", fmt.Sprintf("%d:yes", trackIndex))
+		} else {
+			args = append(args, "--visual-impaired-flag", fmt.Sprintf("%d:no", trackIndex))
+		}
+
+		if track.Info.Properties.FlagCommentary {
+			args = append(args, "--commentary-flag", fmt.Sprintf("%d:yes", trackIndex))
+		} else {
+			args = append(args, "--commentary-flag", fmt.Sprintf("%d:no", trackIndex))
+		}
+
+		if track.Operations.Stretch != 0 && track.Operations.Stretch != 1 {
+			args = append(args, "--sync", fmt.Sprintf("%d:%d,%s", trackIndex, track.Operations.Delay, strconv.FormatFloat(track.Operations.Stretch, 'f', -1, 64)))
+		} else if track.Operations.Delay != 0 {
 			args = append(args, "--sync", fmt.Sprintf("%d:%d", trackIndex, track.Operations.Delay))
 		}
 
 		args = append(args, track.FilePath)
 	}
 
+	if len(cont.TrackOrder) > 0 {
+		order, err := trackOrderArg(cont)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--track-order", order)
+	}
+
 	if cont.Chapters != "" {
 		args = append(args, "--chapters", cont.Chapters)
 	}
@@ -68,14 +97,42 @@ func Merge(output string, cont ExtractedContainer) error {
 	}
 
 	log.Tracef("Executing mkvmerge with args: %v", args)
-	cmd := exec.Command("mkvmerge", args...)
-	if logStr, err := cmd.Output(); err != nil {
-		var exitErr *exec.ExitError
-		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
-			log.Errorf(string(logStr))
+	if logStr, err := tools.Run(tools.MKVMerge, args...); err != nil {
+		// Exit code 1 means the file was written with warnings
+		var exitErr *tools.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+			log.Error(string(logStr))
 			return fmt.Errorf("mkvmerge error: %v", err)
 		}
 	}
 
 	return nil
 }
+
+// Verify checks that the merged file contains the tracks of the container.
+func Verify(output string, cont ExtractedContainer) error {
+	identity, err := Scan(output)
+	if err != nil {
+		return err
+	}
+
+	expected := make(map[string]int)
+	for _, t := range cont.Tracks {
+		expected[t.Info.Type]++
+	}
+	found := make(map[string]int)
+	for _, t := range identity.Tracks {
+		found[t.Type]++
+	}
+
+	for trackType, count := range expected {
+		if found[trackType] != count {
+			return fmt.Errorf("merged file has %d %s tracks, expected %d", found[trackType], trackType, count)
+		}
+	}
+	if len(identity.Tracks) != len(cont.Tracks) {
+		return fmt.Errorf("merged file has %d tracks, expected %d", len(identity.Tracks), len(cont.Tracks))
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package mkv
+
+import (
+	"testing"
+	"videorepack/tools"
+)
+
+func TestMerge(t *testing.T) {
+	replay := useReplay(t)
+
+	spanish, _ := FromIETFName("es-ES")
+	cont := ExtractedContainer{
+		Tracks: []ExtractedTrack{
+			{Info: Track{ID: 0, Type: "video"}, FilePath: "/tmp/track_0.hevc", TimeMapPath: "/tmp/track_0_timemap.txt"},
+			{
+				Info:       Track{ID: 1, Type: "audio", Properties: TrackProperties{LanguageIETF: spanish, DefaultTrack: true, TrackName: " Castellano "}},
+				Operations: TrackOperations{Delay: 120},
+				FilePath:   "/tmp/track_1.eac3",
+			},
+			{
+				Info:       Track{ID: 2, Type: "subtitles"},
+				Operations: TrackOperations{Delay: -500, Stretch: 1.0427},
+				FilePath:   "/tmp/track_2.sup",
+			},
+		},
+		Chapters: "/tmp/chapters.xml",
+	}
+
+	// mkvmerge exits with 1 when the output was written with warnings
+	replay.On(tools.MKVMerge, []string{
+		"-o", "out.mkv",
+		"--track-name", "0:", "--timecodes", "0:/tmp/track_0_timemap.txt",
+		"--default-track-flag", "0:no", "--forced-display-flag", "0:no", "--original-flag", "0:no",
+		"--hearing-impaired-flag", "0:no", "--visual-impaired-flag", "0:no", "--commentary-flag", "0:no",
+		"/tmp/track_0.hevc",
+		"--track-name", "0:Castellano", "--language", "0:es-ES",
+		"--default-track-flag", "0:yes", "--forced-display-flag", "0:no", "--original-flag", "0:no",
+		"--hearing-impaired-flag", "0:no", "--visual-impaired-flag", "0:no", "--commentary-flag", "0:no",
+		"--sync", "0:120",
+		"/tmp/track_1.eac3",
+		"--track-name", "0:",
+		"--default-track-flag", "0:no", "--forced-display-flag", "0:no", "--original-flag", "0:no",
+		"--hearing-impaired-flag", "0:no", "--visual-impaired-flag", "0:no", "--commentary-flag", "0:no",
+		"--sync", "0:-500,1.0427",
+		"/tmp/track_2.sup",
+		"--chapters", "/tmp/chapters.xml",
+	}, "Warning: something", 1)
+
+	if err := Merge("out.mkv", cont); err != nil {
+		t.Fatal(err)
+	}
+	if len(replay.Calls) != 1 {
+		t.Errorf("expected a single mkvmerge call, got %d", len(replay.Calls))
+	}
+}
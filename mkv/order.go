@@ -0,0 +1,159 @@
+package mkv
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Languages put before the others by TrackOrder.First
+const (
+	OrderMainFirst     = "main"
+	OrderOriginalFirst = "original"
+)
+
+// Language groups of a TrackRank
+const (
+	RankMain      = "main"
+	RankOriginal  = "original"
+	RankPreferred = "preferred"
+	RankOther     = "other"
+)
+
+// trackTypeOrder is the order of the track types in the output: video, audio, subtitles, others.
+var trackTypeOrder = map[string]int{
+	"video":     0,
+	"audio":     1,
+	"subtitles": 2,
+}
+
+// TrackOrder is the policy that orders the tracks of each type in the output file. Languages come
+// first (the main or the original language, then the preferred ones and the rest alphabetically),
+// then the flags of the tracks of the same language.
+type TrackOrder struct {
+	First     string   `json:"first"`     // "main" or "original" language first, empty to follow Languages
+	Languages []string `json:"languages"` // Preferred language order, e.g. ["es-ES", "ja", "en"]
+	// Forced subtitles before the full ones of the same language, otherwise after them
+	ForcedFirst bool `json:"forced_first"`
+	// SDH subtitles after all the other subtitles
+	SDHLast bool `json:"sdh_last"`
+	// Commentary and audio description tracks after all the other tracks of their type
+	CommentaryLast bool `json:"commentary_last"`
+}
+
+// TrackRank is the place of a track in the ordering policy, from its most significant criterion.
+type TrackRank struct {
+	Track         *ExtractedTrack
+	Type          int
+	Supplementary bool   // Commentary or audio description placed last
+	SDH           bool   // SDH placed last
+	Language      string // RankMain, RankOriginal, RankPreferred or RankOther
+	Preference    int    // Position in TrackOrder.Languages, for RankPreferred
+	BaseMatch     bool   // Only the base language matches, e.g. es for es-ES, placed after exact tags
+	Forced        bool
+}
+
+// Rank orders the tracks by the policy and returns their ranks in output order.
+func (o *TrackOrder) Rank(tracks []ExtractedTrack, main LocaleInfo, original LocaleInfo) []TrackRank {
+	var preferred []LocaleInfo
+	for _, lang := range o.Languages {
+		if tag, err := FromIETFName(lang); err == nil {
+			preferred = append(preferred, tag)
+		}
+	}
+
+	ranks := make([]TrackRank, len(tracks))
+	for i := range tracks {
+		t := &tracks[i]
+		rank := TrackRank{Track: t, Language: RankOther, Forced: t.Info.Properties.ForcedTrack}
+		if typeOrder, ok := trackTypeOrder[t.Info.Type]; ok {
+			rank.Type = typeOrder
+		} else {
+			rank.Type = 99
+		}
+		rank.Supplementary = o.CommentaryLast && t.Info.Properties.Supplementary()
+		rank.SDH = o.SDHLast && t.Info.Properties.FlagHearingImpaired
+
+		lang := t.Info.Properties.LanguageIETF
+		if match := matchLanguage(lang, []LocaleInfo{main}); o.First == OrderMainFirst && match != -1 {
+			rank.Language = RankMain
+			rank.BaseMatch = match > 0
+		} else if match := matchLanguage(lang, []LocaleInfo{original}); o.First == OrderOriginalFirst && match != -1 {
+			rank.Language = RankOriginal
+			rank.BaseMatch = match > 0
+		} else if pos, exact := preferredLanguage(preferred, lang); pos != -1 {
+			rank.Language = RankPreferred
+			rank.Preference = pos
+			rank.BaseMatch = !exact
+		}
+		ranks[i] = rank
+	}
+
+	languageGroups := map[string]int{RankMain: 0, RankOriginal: 0, RankPreferred: 1, RankOther: 2}
+	slices.SortStableFunc(ranks, func(a, b TrackRank) int {
+		if a.Type != b.Type {
+			return a.Type - b.Type
+		}
+		if a.Supplementary != b.Supplementary {
+			return compareFlag(a.Supplementary, b.Supplementary)
+		}
+		if a.SDH != b.SDH {
+			return compareFlag(a.SDH, b.SDH)
+		}
+		if a.Language != b.Language {
+			return languageGroups[a.Language] - languageGroups[b.Language]
+		}
+		if a.Language == RankPreferred && a.Preference != b.Preference {
+			return a.Preference - b.Preference
+		}
+		if a.BaseMatch != b.BaseMatch {
+			return compareFlag(a.BaseMatch, b.BaseMatch)
+		}
+		if c := cmp.Compare(a.Track.Info.Properties.LanguageIETF.String(), b.Track.Info.Properties.LanguageIETF.String()); c != 0 {
+			return c
+		}
+		if a.Forced != b.Forced {
+			if o.ForcedFirst {
+				return -compareFlag(a.Forced, b.Forced)
+			}
+			return compareFlag(a.Forced, b.Forced)
+		}
+		return 0
+	})
+	return ranks
+}
+
+// preferredLanguage returns the position of a language among the preferred ones, matching the base
+// language when no tag is the same, or -1. Undetermined languages never match.
+func preferredLanguage(preferred []LocaleInfo, lang LocaleInfo) (int, bool) {
+	match := matchLanguage(lang, preferred)
+	if match >= len(preferred) {
+		return match - len(preferred), false
+	}
+	return match, match != -1
+}
+
+// compareFlag orders the tracks without a flag before the ones with it.
+func compareFlag(a, b bool) int {
+	if a == b {
+		return 0
+	} else if a {
+		return 1
+	}
+	return -1
+}
+
+// trackOrderArg returns the --track-order value for the TrackOrder of the container. Each track is
+// the only track of its input file.
+func trackOrderArg(cont ExtractedContainer) (string, error) {
+	var order []string
+	for _, id := range cont.TrackOrder {
+		pos := slices.IndexFunc(cont.Tracks, func(t ExtractedTrack) bool { return t.Info.ID == id })
+		if pos == -1 {
+			return "", fmt.Errorf("track %d of the track order not found", id)
+		}
+		order = append(order, fmt.Sprintf("%d:0", pos))
+	}
+	return strings.Join(order, ","), nil
+}
@@ -0,0 +1,84 @@
+package mkv
+
+import (
+	"slices"
+	"testing"
+	"videorepack/tools"
+)
+
+func TestTrackOrder(t *testing.T) {
+	spanish, _ := FromIETFName("es-ES")
+	japanese, _ := FromIETFName("ja")
+	subtitle := func(id int, lang LocaleInfo, forced bool, sdh bool) ExtractedTrack {
+		return ExtractedTrack{Info: Track{ID: id, Type: "subtitles", Properties: TrackProperties{LanguageIETF: lang, ForcedTrack: forced, FlagHearingImpaired: sdh}}}
+	}
+	commentary := audioTrack(4, "ja", "A_AAC", 2)
+	commentary.Info.Properties.FlagCommentary = true
+
+	tracks := []ExtractedTrack{
+		subtitle(10, spanish, false, true),
+		audioTrack(1, "en", "A_AC3", 6),
+		subtitle(11, japanese, false, false),
+		audioTrack(2, "es-ES", "A_EAC3", 6),
+		commentary,
+		subtitle(12, spanish, false, false),
+		audioTrack(3, "ja", "A_AAC", 2),
+		subtitle(13, spanish, true, false),
+		audioTrack(5, "es", "A_AC3", 2),
+		{Info: Track{ID: 0, Type: "video"}},
+	}
+
+	// The es track matches the es-ES main and preferred languages by its base language, after the
+	// exact tags
+	for _, c := range []struct {
+		order TrackOrder
+		want  []int
+	}{
+		{TrackOrder{First: OrderMainFirst, ForcedFirst: true, SDHLast: true, CommentaryLast: true}, []int{0, 2, 5, 1, 3, 4, 13, 12, 11, 10}},
+		{TrackOrder{First: OrderOriginalFirst, Languages: []string{"es"}, SDHLast: true, CommentaryLast: true}, []int{0, 3, 5, 2, 1, 4, 11, 12, 13, 10}},
+		{TrackOrder{Languages: []string{"ja", "es-ES", "en"}}, []int{0, 4, 3, 2, 5, 1, 11, 10, 12, 13}},
+	} {
+		var got []int
+		for _, rank := range c.order.Rank(tracks, spanish, japanese) {
+			got = append(got, rank.Track.Info.ID)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%+v: expected order %v, got %v", c.order, c.want, got)
+		}
+	}
+}
+
+func TestMergeTrackOrder(t *testing.T) {
+	replay := useReplay(t)
+	cont := ExtractedContainer{
+		Tracks: []ExtractedTrack{
+			{Info: Track{ID: 0, Type: "video"}, FilePath: "/tmp/track_0.hevc"},
+			{Info: Track{ID: 1, Type: "audio"}, FilePath: "/tmp/track_1.ac3"},
+			{Info: Track{ID: 2, Type: "audio"}, FilePath: "/tmp/track_2.eac3"},
+		},
+		TrackOrder: []int{0, 2, 1},
+	}
+
+	replay.On(tools.MKVMerge, []string{
+		"-o", "out.mkv",
+		"--track-name", "0:", "--default-track-flag", "0:no", "--forced-display-flag", "0:no", "--original-flag", "0:no",
+		"--hearing-impaired-flag", "0:no", "--visual-impaired-flag", "0:no", "--commentary-flag", "0:no",
+		"/tmp/track_0.hevc",
+		"--track-name", "0:", "--default-track-flag", "0:no", "--forced-display-flag", "0:no", "--original-flag", "0:no",
+		"--hearing-impaired-flag", "0:no", "--visual-impaired-flag", "0:no", "--commentary-flag", "0:no",
+		"/tmp/track_1.ac3",
+		"--track-name", "0:", "--default-track-flag", "0:no", "--forced-display-flag", "0:no", "--original-flag", "0:no",
+		"--hearing-impaired-flag", "0:no", "--visual-impaired-flag", "0:no", "--commentary-flag", "0:no",
+		"/tmp/track_2.eac3",
+		"--track-order", "0:0,2:0,1:0",
+	}, "", 0)
+
+	if err := Merge("out.mkv", cont); err != nil {
+		t.Fatal(err)
+	}
+
+	cont.TrackOrder = []int{0, 5}
+	if err := Merge("out.mkv", cont); err == nil {
+		t.Error("expected an error for an unknown track in the track order")
+	}
+}
@@ -0,0 +1,160 @@
+package mkv
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PatchRule fixes the metadata of the scanned tracks from the naming conventions of release groups,
+// e.g. a Spanish track named "Latino" is tagged es-419. Patterns are regular expressions and every
+// pattern set must match. All the matching rules are applied, in order.
+type PatchRule struct {
+	Name      string   `json:"name"`       // Shown in the logs
+	Types     []string `json:"types"`      // Track types (audio, subtitles...), empty matches every type
+	TrackName string   `json:"track_name"` // Matched against the track name
+	Codec     string   `json:"codec"`      // Matched against the codec ID and the codec name
+	Language  string   `json:"language"`   // Matched against the IETF tag, "und" when not set
+
+	Set PatchActions `json:"set"`
+
+	trackName *regexp.Regexp
+	codec     *regexp.Regexp
+	language  *regexp.Regexp
+	tag       LocaleInfo
+}
+
+// PatchActions are the changes a rule makes to the matching tracks. Unset flags are left as they are.
+type PatchActions struct {
+	Language        string `json:"language"` // IETF tag
+	Forced          *bool  `json:"forced"`
+	HearingImpaired *bool  `json:"hearing_impaired"`
+	Commentary      *bool  `json:"commentary"`
+	Original        *bool  `json:"original"`
+}
+
+// PatchRules are applied to the tracks of every scanned file.
+var PatchRules = mustCompilePatchRules(DefaultPatchRules())
+
+// SetPatchRules replaces the rules applied to the scanned files.
+func SetPatchRules(rules []PatchRule) error {
+	compiled := slices.Clone(rules)
+	for i := range compiled {
+		if err := compiled[i].Compile(); err != nil {
+			return fmt.Errorf("patch rule %d (%s): %v", i, compiled[i].Name, err)
+		}
+	}
+	PatchRules = compiled
+	return nil
+}
+
+func mustCompilePatchRules(rules []PatchRule) []PatchRule {
+	for i := range rules {
+		if err := rules[i].Compile(); err != nil {
+			panic(fmt.Sprintf("patch rule %s: %v", rules[i].Name, err))
+		}
+	}
+	return rules
+}
+
+// DefaultPatchRules returns the built-in rules for the usual Spanish and English track names.
+func DefaultPatchRules() []PatchRule {
+	forced := true
+	return []PatchRule{
+		{Name: "European Spanish", TrackName: `(?i)european spanish|\bcastellano\b|\bespañol \(españa\)`, Set: PatchActions{Language: "es-ES"}},
+		{Name: "Latin American Spanish", TrackName: `(?i)latin american spanish|\blatino\b|\bespañol \((latinoamérica|latam|méxico)\)`, Language: `^(es|und)$`, Set: PatchActions{Language: "es-419"}},
+		{Name: "Brazilian Portuguese", TrackName: `(?i)brazilian portuguese|\bportuguês \(brasil\)`, Set: PatchActions{Language: "pt-BR"}},
+		{Name: "Arabic (Saudi Arabia)", TrackName: `Arabic \(Saudi Arabia\)`, Set: PatchActions{Language: "ar-SA"}},
+		{Name: "Traditional Chinese", TrackName: `Chinese \(Taiwan\)`, Set: PatchActions{Language: "zh-TW"}},
+		{Name: "Simplified Chinese", TrackName: `Chinese \((Simplified|Mainland China)\)`, Set: PatchActions{Language: "zh-CN"}},
+		{Name: "Forced subtitles", Types: []string{"subtitles"}, TrackName: `(?i)\b(forzados?|forced|signs( ?(&|and) ?songs)?)\b`, Set: PatchActions{Forced: &forced}},
+	}
+}
+
+// Compile checks the patterns and the language of the rule.
+func (r *PatchRule) Compile() error {
+	var err error
+	for _, p := range []struct {
+		pattern string
+		re      **regexp.Regexp
+	}{{r.TrackName, &r.trackName}, {r.Codec, &r.codec}, {r.Language, &r.language}} {
+		*p.re = nil
+		if p.pattern != "" {
+			if *p.re, err = regexp.Compile(p.pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.tag = LocaleInfo{}
+	if r.Set.Language != "" {
+		if r.tag, err = FromIETFName(r.Set.Language); err != nil {
+			return fmt.Errorf("invalid language %s: %v", r.Set.Language, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a track. The rule must be compiled.
+func (r *PatchRule) Matches(t *Track) bool {
+	if len(r.Types) > 0 && !slices.Contains(r.Types, t.Type) {
+		return false
+	}
+	if r.trackName != nil && !r.trackName.MatchString(t.Properties.TrackName) {
+		return false
+	}
+	if r.codec != nil && !r.codec.MatchString(t.Properties.CodecID) && !r.codec.MatchString(t.Codec) {
+		return false
+	}
+	if r.language != nil && !r.language.MatchString(t.Properties.LanguageIETF.String()) {
+		return false
+	}
+	return true
+}
+
+// Apply makes the changes of the rule to a track and describes them.
+func (r *PatchRule) Apply(t *Track) []string {
+	var changes []string
+	if r.Set.Language != "" && t.Properties.LanguageIETF != r.tag {
+		changes = append(changes, fmt.Sprintf("language %s → %s", t.Properties.LanguageIETF.String(), r.tag.String()))
+		t.Properties.LanguageIETF = r.tag
+	}
+
+	for _, f := range []struct {
+		name  string
+		value *bool
+		flag  *bool
+	}{
+		{"forced", r.Set.Forced, &t.Properties.ForcedTrack},
+		{"hearing impaired", r.Set.HearingImpaired, &t.Properties.FlagHearingImpaired},
+		{"commentary", r.Set.Commentary, &t.Properties.FlagCommentary},
+		{"original", r.Set.Original, &t.Properties.FlagOriginal},
+	} {
+		if f.value != nil && *f.flag != *f.value {
+			changes = append(changes, fmt.Sprintf("%s %v", f.name, *f.value))
+			*f.flag = *f.value
+		}
+	}
+	return changes
+}
+
+// patchTracks applies the patch rules to the scanned tracks.
+func patchTracks(identity *Identity) {
+	for i := range identity.Tracks {
+		tr := &identity.Tracks[i]
+		for j := range PatchRules {
+			rule := &PatchRules[j]
+			if !rule.Matches(tr) {
+				continue
+			}
+			if changes := rule.Apply(tr); len(changes) > 0 {
+				log.Infof("Patch rule %q on track %d (%q): %s", rule.Name, tr.ID, tr.Properties.TrackName, strings.Join(changes, ", "))
+			} else {
+				log.Debugf("Patch rule %q on track %d (%q): nothing to change", rule.Name, tr.ID, tr.Properties.TrackName)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package mkv
+
+import (
+	"testing"
+)
+
+func TestPatchRules(t *testing.T) {
+	spanish, _ := FromIETFName("es")
+	track := func(typ string, lang LocaleInfo, name string) Track {
+		return Track{Type: typ, Properties: TrackProperties{LanguageIETF: lang, TrackName: name}}
+	}
+
+	identity := Identity{Tracks: []Track{
+		track("audio", spanish, "Castellano"),
+		track("audio", spanish, "Latino"),
+		track("audio", LocaleInfo{}, "Español (Latinoamérica)"),
+		track("subtitles", spanish, "Forzados"),
+		track("subtitles", LocaleInfo{}, "Signs & Songs"),
+		track("audio", spanish, "Signs"),
+	}}
+	patchIdentity(&identity)
+
+	for i, want := range []string{"es-ES", "es-419", "es-419", "es", "und", "es"} {
+		if got := identity.Tracks[i].Properties.LanguageIETF.String(); got != want {
+			t.Errorf("track %d: expected language %q, got %q", i, want, got)
+		}
+	}
+	for i, want := range []bool{false, false, false, true, true, false} {
+		if got := identity.Tracks[i].Properties.ForcedTrack; got != want {
+			t.Errorf("track %d: expected forced %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestSetPatchRules(t *testing.T) {
+	t.Cleanup(func() { SetPatchRules(DefaultPatchRules()) })
+
+	commentary := true
+	err := SetPatchRules([]PatchRule{{Name: "Group commentary", Codec: `^A_(AAC|OPUS)$`, TrackName: `^Com$`, Set: PatchActions{Commentary: &commentary, Language: "en"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity := Identity{Tracks: []Track{
+		{Type: "audio", Properties: TrackProperties{CodecID: "A_OPUS", TrackName: "Com"}},
+		{Type: "audio", Properties: TrackProperties{CodecID: "A_AC3", TrackName: "Com"}},
+	}}
+	patchIdentity(&identity)
+	if p := identity.Tracks[0].Properties; !p.FlagCommentary || p.LanguageIETF.String() != "en" {
+		t.Errorf("rule not applied: %+v", p)
+	}
+	if identity.Tracks[1].Properties.FlagCommentary {
+		t.Error("rule applied to a track with another codec")
+	}
+
+	if err := SetPatchRules([]PatchRule{{Name: "broken", TrackName: `(`}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if err := SetPatchRules([]PatchRule{{Name: "broken", Set: PatchActions{Language: "not a language"}}}); err == nil {
+		t.Error("expected an error for an invalid language")
+	}
+}
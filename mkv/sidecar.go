@@ -0,0 +1,130 @@
+package mkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sidecarCodecs maps the subtitle file extensions accepted as sidecars to their Matroska codec.
+var sidecarCodecs = map[string]struct {
+	codecID string
+	codec   string
+}{
+	"srt": {"S_TEXT/UTF8", "SubRip/SRT"},
+	"ass": {"S_TEXT/ASS", "SubStationAlpha"},
+	"ssa": {"S_TEXT/SSA", "SubStationAlpha"},
+	"vtt": {"S_TEXT/WEBVTT", "WebVTT"},
+	"sup": {"S_HDMV/PGS", "HDMV PGS"},
+	"idx": {"S_VOBSUB", "VobSub"},
+}
+
+var (
+	sidecarForcedMarkers = []string{"forced", "forzados", "forzado", "forces"}
+	sidecarSDHMarkers    = []string{"sdh", "cc"}
+)
+
+// FindSidecars looks for subtitle files next to the video sharing its base name, such as
+// "Episode 01.es.srt" or "Episode 01.es.forced.ass", and returns them as extracted tracks with IDs
+// starting at firstID. The language, forced and SDH markers of the file name set the track properties,
+// any other marker becomes the track name.
+func FindSidecars(videoPath string, firstID int) ([]ExtractedTrack, error) {
+	dir := filepath.Dir(videoPath)
+	base := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sidecars: %v", err)
+	}
+
+	var tracks []ExtractedTrack
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base+".") {
+			continue
+		}
+
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+		codec, ok := sidecarCodecs[ext]
+		if !ok {
+			continue
+		}
+
+		// The bitmaps of a VobSub index are in the .sub file next to it
+		var extraFiles []string
+		if codec.codecID == "S_VOBSUB" {
+			bitmaps := strings.TrimSuffix(filepath.Join(dir, name), filepath.Ext(name)) + ".sub"
+			if _, err := os.Stat(bitmaps); err != nil {
+				log.Warnf("Skipping VobSub sidecar %s without its .sub file", name)
+				continue
+			}
+			extraFiles = append(extraFiles, bitmaps)
+		}
+
+		c, _ := Codecs.Lookup(codec.codecID)
+		track := Track{
+			ID:    firstID + len(tracks),
+			Type:  "subtitles",
+			Codec: codec.codec,
+			Properties: TrackProperties{
+				CodecID:      codec.codecID,
+				EnabledTrack: true,
+				SubtitleTrackProperties: SubtitleTrackProperties{
+					TextSubtitles: c.Class == CodecText,
+				},
+			},
+		}
+		track.Properties.LanguageIETF, _ = FromIETFName("und")
+
+		markers := strings.TrimSuffix(strings.TrimPrefix(name, base+"."), filepath.Ext(name))
+		applySidecarMarkers(&track.Properties, markers)
+
+		log.Debugf("Found sidecar subtitle %s (language: %s, forced: %v, SDH: %v)", name,
+			track.Properties.LanguageIETF.String(), track.Properties.ForcedTrack, track.Properties.FlagHearingImpaired)
+
+		tracks = append(tracks, ExtractedTrack{
+			Info:       track,
+			FilePath:   filepath.Join(dir, name),
+			ExtraFiles: extraFiles,
+			Sidecar:    filepath.Join(dir, name),
+		})
+	}
+
+	return tracks, nil
+}
+
+func applySidecarMarkers(tp *TrackProperties, markers string) {
+	languageFound := false
+	var nameParts []string
+
+	for _, marker := range strings.Split(markers, ".") {
+		lower := strings.ToLower(marker)
+		switch {
+		case marker == "":
+			continue
+		case slices.Contains(sidecarForcedMarkers, lower):
+			tp.ForcedTrack = true
+		case slices.Contains(sidecarSDHMarkers, lower), lower == "hi" && languageFound:
+			// "hi" alone is the language code of Hindi, after a language it marks hearing impaired subtitles
+			tp.FlagHearingImpaired = true
+		case lower == "default":
+			tp.DefaultTrack = true
+		default:
+			// Language codes are 2 or 3 letters, optionally followed by a region or script
+			if !languageFound && (len(marker) == 2 || len(marker) == 3 || strings.Contains(marker, "-")) {
+				if lang, err := FromIETFName(marker); err == nil {
+					tp.LanguageIETF = lang
+					languageFound = true
+					continue
+				}
+			}
+			nameParts = append(nameParts, marker)
+		}
+	}
+
+	tp.TrackName = strings.Join(nameParts, " ")
+}
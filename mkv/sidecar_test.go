@@ -0,0 +1,85 @@
+package mkv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindSidecars(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"Episode 01.mkv",
+		"Episode 01.es.srt",
+		"Episode 01.es.forced.ass",
+		"Episode 01.en.sdh.srt",
+		"Episode 01.en.hi.srt",
+		"Episode 01.hi.vtt",
+		"Episode 01.es-419.Signs.ass",
+		"Episode 01.ja.sup",
+		"Episode 01.fr.idx",
+		"Episode 01.fr.sub",
+		"Episode 01.de.idx",
+		"Episode 01.nfo",
+		"Episode 02.es.srt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tracks, err := FindSidecars(filepath.Join(dir, "Episode 01.mkv"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		codecID  string
+		language string
+		forced   bool
+		sdh      bool
+		name     string
+	}{
+		"Episode 01.es.srt":           {"S_TEXT/UTF8", "es", false, false, ""},
+		"Episode 01.es.forced.ass":    {"S_TEXT/ASS", "es", true, false, ""},
+		"Episode 01.en.sdh.srt":       {"S_TEXT/UTF8", "en", false, true, ""},
+		"Episode 01.en.hi.srt":        {"S_TEXT/UTF8", "en", false, true, ""},
+		"Episode 01.hi.vtt":           {"S_TEXT/WEBVTT", "hi", false, false, ""},
+		"Episode 01.es-419.Signs.ass": {"S_TEXT/ASS", "es-419", false, false, "Signs"},
+		"Episode 01.ja.sup":           {"S_HDMV/PGS", "ja", false, false, ""},
+		"Episode 01.fr.idx":           {"S_VOBSUB", "fr", false, false, ""},
+	}
+	if len(tracks) != len(expected) {
+		t.Fatalf("expected %d sidecars, got %d", len(expected), len(tracks))
+	}
+
+	ids := make(map[int]bool)
+	for _, tr := range tracks {
+		want, ok := expected[filepath.Base(tr.FilePath)]
+		if !ok {
+			t.Errorf("unexpected sidecar %s", tr.FilePath)
+			continue
+		}
+		p := tr.Info.Properties
+		if p.CodecID != want.codecID || p.LanguageIETF.String() != want.language || p.ForcedTrack != want.forced ||
+			p.FlagHearingImpaired != want.sdh || p.TrackName != want.name {
+			t.Errorf("%s: unexpected properties codec=%s lang=%s forced=%v sdh=%v name=%q", filepath.Base(tr.FilePath),
+				p.CodecID, p.LanguageIETF.String(), p.ForcedTrack, p.FlagHearingImpaired, p.TrackName)
+		}
+		if tr.Sidecar != tr.FilePath || tr.Info.Type != "subtitles" {
+			t.Errorf("%s: not marked as a subtitle sidecar", tr.FilePath)
+		}
+		if tr.Info.ID < 10 || ids[tr.Info.ID] {
+			t.Errorf("%s: invalid track ID %d", tr.FilePath, tr.Info.ID)
+		}
+		ids[tr.Info.ID] = true
+
+		if p.CodecID == "S_VOBSUB" {
+			if files := tr.Files(); len(files) != 2 || filepath.Base(files[1]) != "Episode 01.fr.sub" {
+				t.Errorf("%s: expected the .sub file with the index, got %v", tr.FilePath, files)
+			}
+		} else if len(tr.Files()) != 1 || p.TextSubtitles == (p.CodecID == "S_HDMV/PGS") {
+			t.Errorf("%s: unexpected files %v or text flag %v", tr.FilePath, tr.Files(), p.TextSubtitles)
+		}
+	}
+}
@@ -12,6 +12,14 @@ type VideoTrackProperties struct {
 	DisplayDimensions string `json:"display_dimensions"`
 	DisplayUnit       int    `json:"display_unit"`
 	PixelDimensions   string `json:"pixel_dimensions"`
+
+	// Colour elements of the track (ITU-T H.273 code points), zero when not set
+	ColorPrimaries               int     `json:"color_primaries"`
+	ColorTransferCharacteristics int     `json:"color_transfer_characteristics"`
+	ColorMatrixCoefficients      int     `json:"color_matrix_coefficients"`
+	MaxContentLight              int     `json:"max_content_light"`
+	MaxFrameLight                int     `json:"max_frame_light"`
+	MaxLuminance                 float64 `json:"max_luminance"`
 }
 
 type AudioTrackProperties struct {
@@ -25,51 +33,41 @@ type SubtitleTrackProperties struct {
 }
 
 type TrackProperties struct {
-	UID                big.Int        `json:"uid"`
-	CodecID            string         `json:"codec_id"`
-	CodecPrivateData   types.HexBytes `json:"codec_private_data"`
-	CodecPrivateLength int            `json:"codec_private_length"`
-	DefaultDuration    uint64         `json:"default_duration"`
-	DefaultTrack       bool           `json:"default_track"`
-	EnabledTrack       bool           `json:"enabled_track"`
-	ForcedTrack        bool           `json:"forced_track"`
-	FlagOriginal       bool           `json:"flag_original"`
-	Language           string         `json:"language"`
-	LanguageIETF       LocaleInfo     `json:"language_ietf"`
-	MinimumTimestamp   int            `json:"minimum_timestamp"`
-	Number             int            `json:"number"`
-	Packetizer         string         `json:"packetizer"`
-	TrackName          string         `json:"track_name"`
-	NumIndexEntries    int            `json:"num_index_entries"`
+	UID                 big.Int         `json:"uid"`
+	CodecID             string          `json:"codec_id"`
+	CodecPrivateData    types.HexBytes  `json:"codec_private_data"`
+	CodecPrivateLength  int             `json:"codec_private_length"`
+	DefaultDuration     uint64          `json:"default_duration"`
+	DefaultTrack        bool            `json:"default_track"`
+	EnabledTrack        bool            `json:"enabled_track"`
+	ForcedTrack         bool            `json:"forced_track"`
+	FlagOriginal        bool            `json:"flag_original"`
+	FlagHearingImpaired bool            `json:"flag_hearing_impaired"`
+	FlagVisualImpaired  bool            `json:"flag_visual_impaired"`
+	FlagCommentary      bool            `json:"flag_commentary"`
+	Language            string          `json:"language"`
+	LanguageIETF        LocaleInfo      `json:"language_ietf"`
+	MinimumTimestamp    int             `json:"minimum_timestamp"`
+	Number              int             `json:"number"`
+	Packetizer          string          `json:"packetizer"`
+	TrackName           string          `json:"track_name"`
+	NumIndexEntries     int             `json:"num_index_entries"`
+	TagBps              types.StringInt `json:"tag_bps"`
 
 	VideoTrackProperties
 	AudioTrackProperties
 	SubtitleTrackProperties
 }
 
+// Supplementary reports whether the track is commentary or audio description, which are never
+// chosen as default tracks.
+func (tp *TrackProperties) Supplementary() bool {
+	return tp.FlagCommentary || tp.FlagVisualImpaired
+}
+
 // FileExtension returns the suggested file extension for the track based on its codec.
 func (tp *TrackProperties) FileExtension() string {
-	if strings.Index(tp.CodecID, "V_MPEG4/ISO/AVC") != -1 {
-		return "h264"
-	} else if strings.Index(tp.CodecID, "V_MPEGH/ISO/HEVC") != -1 {
-		return "hevc"
-	} else if strings.Index(tp.CodecID, "A_AAC") != -1 {
-		return "aac"
-	} else if strings.Index(tp.CodecID, "A_AC3") != -1 {
-		return "ac3"
-	} else if strings.Index(tp.CodecID, "A_EAC3") != -1 {
-		return "eac3"
-	} else if strings.Index(tp.CodecID, "A_DTS") != -1 {
-		return "dts"
-	} else if strings.Index(tp.CodecID, "A_FLAC") != -1 {
-		return "flac"
-	} else if strings.Index(tp.CodecID, "S_TEXT/UTF8") != -1 {
-		return "srt"
-	} else if strings.Index(tp.CodecID, "S_TEXT/ASS") != -1 {
-		return "ass"
-	}
-
-	return "bin"
+	return tp.Codec().Extension
 }
 
 type Track struct {
@@ -79,6 +77,22 @@ type Track struct {
 	Properties TrackProperties `json:"properties"`
 }
 
+// Resolution returns the standard resolution label of a video track, e.g. "1080p", from its display
+// height.
+func (tp *Track) Resolution() string {
+	dimensionParts := strings.Split(tp.Properties.DisplayDimensions, "x")
+	if len(dimensionParts) != 2 {
+		return ""
+	}
+	height, _ := strconv.Atoi(dimensionParts[1])
+	for _, standard := range []int{4320, 2160, 1440, 1080, 720, 480, 360, 240} {
+		if height >= standard {
+			return fmt.Sprintf("%dp", standard)
+		}
+	}
+	return ""
+}
+
 func (tp *Track) NamingMetadata() []string {
 	var metadata []string
 
@@ -87,38 +101,21 @@ func (tp *Track) NamingMetadata() []string {
 	}
 
 	if tp.Type == "video" {
-		if tp.Properties.DisplayDimensions != "" {
-			dimensionParts := strings.Split(tp.Properties.DisplayDimensions, "x")
-			if len(dimensionParts) == 2 {
-				height, _ := strconv.Atoi(dimensionParts[1])
-				if height >= 4320 {
-					height = 4320
-				} else if height >= 2160 {
-					height = 2160
-				} else if height >= 1440 {
-					height = 1440
-				} else if height >= 1080 {
-					height = 1080
-				} else if height >= 720 {
-					height = 720
-				} else if height >= 480 {
-					height = 480
-				} else if height >= 360 {
-					height = 360
-				} else if height >= 240 {
-					height = 240
-				} else {
-					height = 0
-				}
-				if height > 0 {
-					metadata = append(metadata, fmt.Sprintf("%dp", height))
-				}
-			}
+		if resolution := tp.Resolution(); resolution != "" {
+			metadata = append(metadata, resolution)
 		}
+		metadata = append(metadata, tp.HDR().Tags()...)
 	}
 
 	if tp.Properties.CodecID != "" {
-		metadata = append(metadata, strings.ToUpper(tp.Properties.FileExtension()))
+		codec := tp.CodecName()
+		if layout := tp.ChannelLayout(); tp.Type == "audio" && layout != "" {
+			codec += " " + layout
+		}
+		metadata = append(metadata, codec)
+		if details, err := tp.Properties.CodecDetails(); err == nil && tp.Type == "video" && details.BitDepth > 8 {
+			metadata = append(metadata, fmt.Sprintf("%dbit", details.BitDepth))
+		}
 	}
 
 	return metadata
@@ -0,0 +1,140 @@
+package mkv
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Languages of the names generated by TrackNames
+const (
+	NamesNative = "native" // Each track named in its own language
+	NamesMain   = "main"   // Every track named in the main language
+)
+
+// TrackNames are the templates of the generated track names, per track type. Templates replace
+// placeholders such as {lang_native}, {codec} or {channels}; a group with a flag name, such as
+// "{ - Forced}", is only written for the tracks with that flag, with the flag name translated.
+// An empty template leaves the tracks without name.
+type TrackNames struct {
+	Video     string `json:"video"`
+	Audio     string `json:"audio"`
+	Subtitles string `json:"subtitles"`
+	// Language of {lang}, {region} and the flag names: "native" or "main"
+	Language string `json:"language"`
+}
+
+var (
+	templateGroupPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+	templateFlagPattern  = regexp.MustCompile(`\b(Forced|SDH|Commentary|AD|Original)\b`)
+	spacesPattern        = regexp.MustCompile(`\s{2,}`)
+)
+
+// flagLabels translates the flag names of the templates, by base language. Other languages use the
+// English names.
+var flagLabels = map[string]map[string]string{
+	"en": {"Forced": "Forced", "SDH": "SDH", "Commentary": "Commentary", "AD": "Audio Description", "Original": "Original"},
+	"es": {"Forced": "Forzados", "SDH": "SDH", "Commentary": "Comentarios", "AD": "Audiodescripción", "Original": "Original"},
+	"gl": {"Forced": "Forzados", "SDH": "SDH", "Commentary": "Comentarios", "AD": "Audiodescrición", "Original": "Orixinal"},
+	"ca": {"Forced": "Forçats", "SDH": "SDH", "Commentary": "Comentaris", "AD": "Audiodescripció", "Original": "Original"},
+	"pt": {"Forced": "Forçadas", "SDH": "SDH", "Commentary": "Comentários", "AD": "Audiodescrição", "Original": "Original"},
+	"fr": {"Forced": "Forcés", "SDH": "SME", "Commentary": "Commentaires", "AD": "Audiodescription", "Original": "VO"},
+	"it": {"Forced": "Forzati", "SDH": "SDH", "Commentary": "Commento", "AD": "Audiodescrizione", "Original": "Originale"},
+	"de": {"Forced": "Erzwungen", "SDH": "SDH", "Commentary": "Kommentar", "AD": "Audiodeskription", "Original": "Original"},
+}
+
+// Name generates the name of a track from the template of its type. main is the main language of
+// the profile.
+func (tn *TrackNames) Name(t *Track, main LocaleInfo) string {
+	var template string
+	switch t.Type {
+	case "video":
+		template = tn.Video
+	case "audio":
+		template = tn.Audio
+	case "subtitles":
+		template = tn.Subtitles
+	}
+	if template == "" {
+		return ""
+	}
+
+	lang := t.Properties.LanguageIETF
+	names := lang
+	if tn.Language == NamesMain {
+		names = main
+	}
+	flags := map[string]bool{
+		"Forced":     t.Properties.ForcedTrack,
+		"SDH":        t.Properties.FlagHearingImpaired,
+		"Commentary": t.Properties.FlagCommentary,
+		"AD":         t.Properties.FlagVisualImpaired,
+		"Original":   t.Properties.FlagOriginal,
+	}
+
+	name := templateGroupPattern.ReplaceAllStringFunc(template, func(group string) string {
+		content := group[1 : len(group)-1]
+		switch content {
+		case "lang":
+			return languageName(lang, names)
+		case "lang_native":
+			return languageName(lang, lang)
+		case "lang_main":
+			return languageName(lang, main)
+		case "lang_english":
+			return lang.LangEnglishName()
+		case "region":
+			return capitalize(lang.RegionNameIn(names))
+		case "ietf":
+			return lang.String()
+		case "codec":
+			return t.CodecName()
+		case "channels":
+			return t.ChannelLayout()
+		case "resolution":
+			return t.Resolution()
+		case "hdr":
+			return strings.Join(t.HDR().Tags(), " ")
+		}
+
+		flag := templateFlagPattern.FindString(content)
+		if flag == "" {
+			return group
+		}
+		if !flags[flag] {
+			return ""
+		}
+		return strings.Replace(content, flag, flagLabel(flag, names), 1)
+	})
+
+	return strings.TrimSpace(spacesPattern.ReplaceAllString(name, " "))
+}
+
+// languageName names a language with its region, e.g. "Español (España)", in another language.
+func languageName(lang LocaleInfo, in LocaleInfo) string {
+	if lang.String() == "und" {
+		return ""
+	}
+	name := capitalize(lang.LangNameIn(in))
+	if region := lang.RegionNameIn(in); region != "" {
+		name += " (" + capitalize(region) + ")"
+	}
+	return name
+}
+
+func flagLabel(flag string, in LocaleInfo) string {
+	base, _ := in.Base()
+	if labels, ok := flagLabels[base.String()]; ok {
+		return labels[flag]
+	}
+	return flagLabels["en"][flag]
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,42 @@
+package mkv
+
+import "testing"
+
+func TestTrackNames(t *testing.T) {
+	spanish, _ := FromIETFName("es-ES")
+	names := TrackNames{
+		Audio:     "{lang_native} {codec} {channels}{ - Forced}{ - SDH}",
+		Subtitles: "{lang}{ - Forced}{ - SDH}{ (Commentary)}",
+		Language:  NamesMain,
+	}
+
+	forced := audioTrack(1, "es-ES", "A_EAC3", 6)
+	forced.Info.Properties.ForcedTrack = true
+	japanese := audioTrack(2, "ja", "A_AAC", 2)
+	latin := audioTrack(3, "es-419", "A_AC3", 2)
+	undetermined := audioTrack(4, "und", "A_FLAC", 1)
+
+	english, _ := FromIETFName("en-US")
+	subtitles := Track{Type: "subtitles", Properties: TrackProperties{LanguageIETF: english, FlagHearingImpaired: true, FlagCommentary: true}}
+
+	for _, c := range []struct {
+		track Track
+		want  string
+	}{
+		{forced.Info, "Español (España) EAC3 5.1 - Forzados"},
+		{japanese.Info, "日本語 AAC 2.0"},
+		{latin.Info, "Español (Latinoamérica) AC3 2.0"},
+		{undetermined.Info, "FLAC 1.0"},
+		{subtitles, "Inglés (Estados Unidos) - SDH (Comentarios)"},
+		{Track{Type: "video", Properties: TrackProperties{CodecID: "V_MPEGH/ISO/HEVC"}}, ""},
+	} {
+		if got := names.Name(&c.track, spanish); got != c.want {
+			t.Errorf("expected %q, got %q", c.want, got)
+		}
+	}
+
+	names.Language = NamesNative
+	if got := names.Name(&subtitles, spanish); got != "English (United States) - SDH (Commentary)" {
+		t.Errorf("unexpected native name %q", got)
+	}
+}
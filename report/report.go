@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Report collects what was changed in every processed file, to show a summary at the end of the run.
+type Report struct {
+	mu    sync.Mutex
+	Files []*File
+}
+
+type File struct {
+	Input   string
+	Output  string
+	Entries []Entry
+}
+
+type Entry struct {
+	Step    string // Short name of the processing step, e.g. "encoding"
+	Message string
+}
+
+// File starts the section of an input file.
+func (r *Report) File(input string) *File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := &File{Input: input}
+	r.Files = append(r.Files, f)
+	return f
+}
+
+// Add records a change made by a processing step. It does nothing on a nil file.
+func (f *File) Add(step string, format string, args ...any) {
+	if f == nil {
+		return
+	}
+	f.Entries = append(f.Entries, Entry{Step: step, Message: fmt.Sprintf(format, args...)})
+}
+
+// Print writes the files with changes, one entry per line.
+func (r *Report) Print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.Files {
+		if len(f.Entries) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, f.Input)
+		if f.Output != "" {
+			fmt.Fprintf(w, "  => %s\n", f.Output)
+		}
+		for _, e := range f.Entries {
+			fmt.Fprintf(w, "  [%s] %s\n", e.Step, e.Message)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package subtitles
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	defaultStyleFormat    = parseFormatLine(styleFormat)
+	defaultEventFormat    = parseFormatLine("Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text")
+	defaultSSAEventFormat = parseFormatLine("Marked, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text")
+
+	overrideBlockPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	// \fn sets the font and \r resets to the event style or to another one
+	fontTagPattern  = regexp.MustCompile(`\\fn([^\\}]*)`)
+	resetTagPattern = regexp.MustCompile(`\\r([^\\}]*)`)
+)
+
+func parseASS(text string, format Format) (*Subtitle, error) {
+	sub := &Subtitle{Format: format}
+
+	section := ""
+	styleFields := defaultStyleFormat
+	eventFields := defaultEventFormat
+	if format == FormatSSA {
+		eventFields = defaultSSAEventFormat
+	}
+
+	for n, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if isSectionHeader(section, trimmed) {
+			section = strings.ToLower(trimmed[1 : len(trimmed)-1])
+			switch section {
+			case "script info", "v4+ styles", "v4 styles", "events":
+			default:
+				sub.ExtraSections = append(sub.ExtraSections, Section{Name: trimmed[1 : len(trimmed)-1]})
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(trimmed, ":")
+		value = strings.TrimLeft(value, " ")
+
+		switch section {
+		case "script info":
+			if !strings.HasPrefix(trimmed, ";") && found {
+				sub.ScriptInfo = append(sub.ScriptInfo, InfoField{Key: key, Value: value})
+			}
+		case "v4+ styles", "v4 styles":
+			if key == "Format" {
+				styleFields = parseFormatLine(value)
+			} else if key == "Style" {
+				sub.Styles = append(sub.Styles, parseStyle(styleFields, value, section == "v4 styles"))
+			}
+		case "events":
+			switch key {
+			case "Format":
+				eventFields = parseFormatLine(value)
+			case "Dialogue", "Comment":
+				event, err := parseEvent(eventFields, value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", n+1, err)
+				}
+				event.Comment = key == "Comment"
+				sub.Events = append(sub.Events, event)
+			}
+		case "":
+			// Content before the first section is ignored by renderers
+		default:
+			extra := &sub.ExtraSections[len(sub.ExtraSections)-1]
+			extra.Lines = append(extra.Lines, line)
+		}
+	}
+
+	return sub, nil
+}
+
+// Sections whose lines are uuencoded data, which may start with "[" and end with "]"
+var embeddedSections = []string{"fonts", "graphics"}
+
+// Sections that can follow the embedded data ones
+var knownSections = []string{"script info", "v4+ styles", "v4 styles", "events", "fonts", "graphics",
+	"aegisub project garbage", "aegisub extradata"}
+
+// isSectionHeader reports whether a line starts a section. Inside embedded data only the known
+// section names end the section.
+func isSectionHeader(section string, line string) bool {
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return false
+	}
+	if slices.Contains(embeddedSections, section) {
+		return slices.Contains(knownSections, strings.ToLower(line[1:len(line)-1]))
+	}
+	return true
+}
+
+// parseFormatLine returns the lowercase field names of a Format line.
+func parseFormatLine(value string) []string {
+	var fields []string
+	for _, f := range strings.Split(value, ",") {
+		fields = append(fields, strings.ToLower(strings.TrimSpace(f)))
+	}
+	return fields
+}
+
+func parseEvent(format []string, value string) (Event, error) {
+	var event Event
+	// The text is the last field and may contain commas
+	fields := strings.SplitN(value, ",", len(format))
+	if len(fields) != len(format) {
+		return event, fmt.Errorf("expected %d fields, found %d", len(format), len(fields))
+	}
+
+	for i, name := range format {
+		field := fields[i]
+		if name != "text" {
+			field = strings.TrimSpace(field)
+		}
+
+		var err error
+		switch name {
+		case "layer":
+			event.Layer, _ = strconv.Atoi(field)
+		case "start":
+			event.Start, err = ParseTimestamp(field)
+		case "end":
+			event.End, err = ParseTimestamp(field)
+		case "style":
+			event.Style = field
+		case "name", "actor":
+			event.Actor = field
+		case "marginl":
+			event.MarginL, _ = strconv.Atoi(field)
+		case "marginr":
+			event.MarginR, _ = strconv.Atoi(field)
+		case "marginv":
+			event.MarginV, _ = strconv.Atoi(field)
+		case "effect":
+			event.Effect = field
+		case "text":
+			event.Text = field
+		}
+		if err != nil {
+			return event, err
+		}
+	}
+
+	return event, nil
+}
+
+func writeASS(s *Subtitle) []byte {
+	var sb strings.Builder
+
+	sb.WriteString("[Script Info]\n")
+	hasScriptType := false
+	for _, f := range s.ScriptInfo {
+		if f.Key == "ScriptType" {
+			// Styles are always written in the v4+ layout
+			f.Value = "v4.00+"
+			hasScriptType = true
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", f.Key, f.Value)
+	}
+	if !hasScriptType {
+		sb.WriteString("ScriptType: v4.00+\n")
+	}
+
+	sb.WriteString("\n[V4+ Styles]\n")
+	sb.WriteString("Format: " + styleFormat + "\n")
+	for _, st := range s.Styles {
+		sb.WriteString("Style: " + st.line() + "\n")
+	}
+
+	sb.WriteString("\n[Events]\n")
+	sb.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, e := range s.Events {
+		kind := "Dialogue"
+		if e.Comment {
+			kind = "Comment"
+		}
+		fmt.Fprintf(&sb, "%s: %d,%s,%s,%s,%s,%d,%d,%d,%s,%s\n", kind, e.Layer,
+			formatASSTimestamp(e.Start), formatASSTimestamp(e.End), e.Style, e.Actor,
+			e.MarginL, e.MarginR, e.MarginV, e.Effect, e.Text)
+	}
+
+	for _, section := range s.ExtraSections {
+		sb.WriteString("\n[" + section.Name + "]\n")
+		for _, line := range section.Lines {
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	return []byte(sb.String())
+}
+
+// Fonts returns the font families the dialogue uses, from the styles of the events and the \fn
+// override tags. The @ prefix of vertical fonts is removed.
+func (s *Subtitle) Fonts() []string {
+	var fonts []string
+	add := func(font string) {
+		font = strings.TrimPrefix(strings.TrimSpace(font), "@")
+		if font != "" && !slices.ContainsFunc(fonts, func(f string) bool { return strings.EqualFold(f, font) }) {
+			fonts = append(fonts, font)
+		}
+	}
+	addStyle := func(name string) {
+		for _, st := range s.Styles {
+			// Renderers ignore the asterisk some tools prepend to the default style name
+			if strings.EqualFold(strings.TrimPrefix(st.Name, "*"), strings.TrimPrefix(name, "*")) {
+				add(st.Fontname)
+				return
+			}
+		}
+	}
+
+	for _, e := range s.Events {
+		if e.Comment {
+			continue
+		}
+		addStyle(e.Style)
+		for _, block := range overrideBlockPattern.FindAllString(e.Text, -1) {
+			for _, m := range fontTagPattern.FindAllStringSubmatch(block, -1) {
+				add(m[1])
+			}
+			for _, m := range resetTagPattern.FindAllStringSubmatch(block, -1) {
+				if m[1] != "" {
+					addStyle(m[1])
+				}
+			}
+		}
+	}
+	return fonts
+}
+
+// FontChars returns the characters drawn with each font, keyed by the lowercase font name. The
+// font of every character follows the event style and the \fn and \r override tags, and the text
+// of vector drawings is skipped.
+func (s *Subtitle) FontChars() map[string][]rune {
+	styleFont := func(name string) string {
+		for _, st := range s.Styles {
+			if strings.EqualFold(strings.TrimPrefix(st.Name, "*"), strings.TrimPrefix(name, "*")) {
+				return st.Fontname
+			}
+		}
+		return ""
+	}
+
+	chars := make(map[string]map[rune]bool)
+	add := func(font string, r rune) {
+		font = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(font), "@"))
+		if font == "" {
+			return
+		}
+		if chars[font] == nil {
+			chars[font] = make(map[rune]bool)
+		}
+		chars[font][r] = true
+	}
+
+	for _, e := range s.Events {
+		if e.Comment {
+			continue
+		}
+		font := styleFont(e.Style)
+		drawing := false
+
+		text := []rune(e.Text)
+		for i := 0; i < len(text); i++ {
+			switch {
+			case text[i] == '{':
+				end := slices.Index(text[i:], '}')
+				if end == -1 {
+					break
+				}
+				for _, tag := range strings.Split(string(text[i+1:i+end]), `\`)[1:] {
+					switch {
+					case strings.HasPrefix(tag, "fn"):
+						if font = strings.TrimSpace(tag[2:]); font == "" {
+							font = styleFont(e.Style)
+						}
+					case strings.HasPrefix(tag, "r"):
+						if font = styleFont(strings.TrimSpace(tag[1:])); tag == "r" || font == "" {
+							font = styleFont(e.Style)
+						}
+					case len(tag) > 1 && tag[0] == 'p' && tag[1] >= '0' && tag[1] <= '9':
+						drawing = tag != "p0"
+					}
+				}
+				i += end
+				continue
+			case text[i] == '\\' && i+1 < len(text):
+				switch text[i+1] {
+				case 'N', 'n':
+					i++
+					continue
+				case 'h':
+					add(font, '\u00a0')
+					i++
+					continue
+				}
+			}
+			if !drawing {
+				add(font, text[i])
+			}
+		}
+	}
+
+	result := make(map[string][]rune, len(chars))
+	for font, set := range chars {
+		for r := range set {
+			result[font] = append(result[font], r)
+		}
+		slices.Sort(result[font])
+	}
+	return result
+}
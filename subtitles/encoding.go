@@ -0,0 +1,153 @@
+package subtitles
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	EncodingUTF8    = "UTF-8"
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+)
+
+// DetectEncoding guesses the character encoding of subtitle data. Byte order marks and valid UTF-8
+// are trusted first. For 8-bit data the hint (usually the track Encoding property) is used when it
+// names a known charset, then the fallback when it isn't a Western one, and otherwise the bytes
+// decide between Windows-1252 and ISO-8859-15.
+func DetectEncoding(data []byte, hint string, fallback string) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	if enc := utf16WithoutBOM(data); enc != "" {
+		return enc
+	}
+
+	if utf8.Valid(data) {
+		return EncodingUTF8
+	}
+
+	if name, ok := knownCharset(hint); ok && name != EncodingUTF8 {
+		return name
+	}
+
+	if name, ok := knownCharset(fallback); ok && name != EncodingUTF8 && !isWestern(name) {
+		return name
+	}
+
+	// 0x80-0x9F are printable in Windows-1252 (€, curly quotes, dashes) but control characters in
+	// the ISO-8859 charsets, where 0xA4 is the euro sign of ISO-8859-15
+	for _, b := range data {
+		if b >= 0x80 && b <= 0x9F {
+			return "windows-1252"
+		}
+	}
+	if bytes.IndexByte(data, 0xA4) != -1 {
+		return "iso-8859-15"
+	}
+	return "windows-1252"
+}
+
+// DecodeToUTF8 converts data in the given encoding to UTF-8, removing any byte order mark.
+func DecodeToUTF8(data []byte, name string) ([]byte, error) {
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", name, err)
+	}
+	return bytes.TrimPrefix(decoded, []byte{0xEF, 0xBB, 0xBF}), nil
+}
+
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToUpper(name) {
+	case EncodingUTF8, "UTF8":
+		return unicode.UTF8BOM, nil
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM), nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %s", name)
+	}
+	return enc, nil
+}
+
+// knownCharset returns the canonical name of a supported charset.
+func knownCharset(name string) (string, bool) {
+	enc, err := lookupEncoding(strings.TrimSpace(name))
+	if err != nil {
+		return "", false
+	}
+
+	switch enc {
+	case unicode.UTF8BOM:
+		return EncodingUTF8, true
+	case unicode.UTF16(unicode.LittleEndian, unicode.UseBOM):
+		return EncodingUTF16LE, true
+	case unicode.UTF16(unicode.BigEndian, unicode.UseBOM):
+		return EncodingUTF16BE, true
+	}
+
+	canonical, err := htmlindex.Name(enc)
+	if err != nil {
+		return "", false
+	}
+	if canonical == "utf-8" {
+		return EncodingUTF8, true
+	}
+	return canonical, true
+}
+
+func isWestern(name string) bool {
+	switch strings.ToLower(name) {
+	case "windows-1252", "iso-8859-1", "iso-8859-15", "latin1", "latin-9":
+		return true
+	}
+	return false
+}
+
+// utf16WithoutBOM recognizes UTF-16 text without byte order mark by the zero high bytes of ASCII
+// characters.
+func utf16WithoutBOM(data []byte) string {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return ""
+	}
+
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			evenZeros++
+		}
+		if data[i+1] == 0 {
+			oddZeros++
+		}
+	}
+
+	pairs := len(data) / 2
+	if oddZeros > pairs*3/4 && evenZeros == 0 {
+		return EncodingUTF16LE
+	}
+	if evenZeros > pairs*3/4 && oddZeros == 0 {
+		return EncodingUTF16BE
+	}
+	return ""
+}
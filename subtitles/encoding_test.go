@@ -0,0 +1,57 @@
+package subtitles
+
+import "testing"
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		hint     string
+		fallback string
+		want     string
+	}{
+		{"utf-8", "¿Qué tal?", "", "windows-1252", EncodingUTF8},
+		{"utf-8 bom", "\xef\xbb\xbfhola", "", "windows-1252", EncodingUTF8},
+		{"utf-16le bom", "\xff\xfeh\x00o\x00", "", "windows-1252", EncodingUTF16LE},
+		{"utf-16be without bom", "\x00h\x00o\x00l\x00a", "", "windows-1252", EncodingUTF16BE},
+		{"windows-1252 quotes", "\x93\xbfQu\xe9 tal?\x94", "", "windows-1252", "windows-1252"},
+		{"iso-8859-15 euro", "Son 5 \xa4, se\xf1or", "", "windows-1252", "iso-8859-15"},
+		{"latin-1 defaults to windows-1252", "Se\xf1or", "", "iso-8859-1", "windows-1252"},
+		{"hint", "\xcf\xf0\xe8\xe2\xe5\xf2", "windows-1251", "windows-1252", "windows-1251"},
+		{"wrong utf-8 hint", "Se\xf1or", "UTF-8", "windows-1252", "windows-1252"},
+		{"non-western fallback", "\xcf\xf0\xe8\xe2\xe5\xf2", "", "windows-1251", "windows-1251"},
+	}
+
+	for _, tt := range tests {
+		if got := DetectEncoding([]byte(tt.data), tt.hint, tt.fallback); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDecodeToUTF8(t *testing.T) {
+	tests := []struct {
+		data     string
+		encoding string
+		want     string
+	}{
+		{"\x93\xbfQu\xe9 tal?\x94", "windows-1252", "“¿Qué tal?”"},
+		{"Son 5 \xa4", "iso-8859-15", "Son 5 €"},
+		{"\xff\xfeh\x00\xf3\x00", EncodingUTF16LE, "hó"},
+		{"\xef\xbb\xbfhola", EncodingUTF8, "hola"},
+	}
+
+	for _, tt := range tests {
+		got, err := DecodeToUTF8([]byte(tt.data), tt.encoding)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.encoding, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.encoding, tt.want, got)
+		}
+	}
+
+	if _, err := DecodeToUTF8([]byte("x"), "klingon"); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}
@@ -0,0 +1,102 @@
+package subtitles
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	soundBracketPattern = regexp.MustCompile(`\[[^\[\]]*\]`)
+	// Parentheses are also used for asides in normal subtitles, only uppercase ones like (SIGHS)
+	// or (RÍE) are taken as sound descriptions
+	soundParenPattern = regexp.MustCompile(`\(\P{Ll}*\p{Lu}\P{Ll}*\)`)
+	// A speaker label is an uppercase name followed by a colon at the start of a line, optionally
+	// after a dialogue dash and some markup
+	speakerLabelPattern = regexp.MustCompile(`^((?:<[^<>]*>|\{[^{}]*\})*)(-\s*)?\p{Lu}[\p{Lu}\d .'-]+:\s*`)
+	leadingDashPattern  = regexp.MustCompile(`^((?:<[^<>]*>|\{[^{}]*\})*)-\s*`)
+	musicNotes          = "♪♫"
+)
+
+// HearingCues tells which hearing impaired annotations a subtitle line has.
+type HearingCues struct {
+	Descriptions bool // [door slams], (SIGHS)
+	Speakers     bool // JOHN: ...
+	Music        bool // ♪
+}
+
+func (c HearingCues) Any() bool {
+	return c.Descriptions || c.Speakers || c.Music
+}
+
+// FindHearingCues looks for the annotations of SDH subtitles in the text of an event.
+func (s *Subtitle) FindHearingCues(e Event) HearingCues {
+	var cues HearingCues
+	for _, line := range strings.Split(s.PlainText(e), "\n") {
+		line = strings.TrimSpace(line)
+		if soundBracketPattern.MatchString(line) || soundParenPattern.MatchString(line) {
+			cues.Descriptions = true
+		}
+		if speakerLabelPattern.MatchString(line) {
+			cues.Speakers = true
+		}
+		if strings.ContainsAny(line, musicNotes) {
+			cues.Music = true
+		}
+	}
+	return cues
+}
+
+// StripHearingImpaired removes sound descriptions, speaker labels and music notes from every
+// event, keeping the markup. Events left without text are removed. It returns the number of
+// events changed or removed.
+func (s *Subtitle) StripHearingImpaired() int {
+	separator := "\n"
+	if s.Format.styled() {
+		separator = `\N`
+	}
+
+	changed := 0
+	events := make([]Event, 0, len(s.Events))
+	for _, e := range s.Events {
+		if e.Comment {
+			events = append(events, e)
+			continue
+		}
+
+		var lines []string
+		for _, line := range strings.Split(e.Text, separator) {
+			line = soundBracketPattern.ReplaceAllString(line, "")
+			line = soundParenPattern.ReplaceAllString(line, "")
+			line = speakerLabelPattern.ReplaceAllString(line, "$1$2")
+			line = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(musicNotes, r) {
+					return -1
+				}
+				return r
+			}, line)
+			line = strings.Join(strings.Fields(line), " ")
+
+			if strings.Trim(PlainText(s.Format, line), " -") != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		// A dialogue dash is only needed when there are several speakers
+		if len(lines) == 1 {
+			lines[0] = leadingDashPattern.ReplaceAllString(lines[0], "$1")
+		}
+
+		text := strings.Join(lines, separator)
+		if text != e.Text {
+			changed++
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		e.Text = text
+		events = append(events, e)
+	}
+
+	s.Events = events
+	return changed
+}
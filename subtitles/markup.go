@@ -0,0 +1,202 @@
+package subtitles
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTagPattern   = regexp.MustCompile(`</?[a-zA-Z][^<>]*>`)
+	fontColorPattern = regexp.MustCompile(`(?i)color\s*=\s*["']?#?([0-9a-f]{6})`)
+	// WebVTT tags may carry classes (<i.yellow>) or annotations (<v Bob>), and there are inline timestamps
+	webVTTTagPattern = regexp.MustCompile(`<(/?)([a-z]+)?[^<>]*>|<\d[\d:.]*>`)
+	webVTTEntities   = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", "\u00a0", "&lrm;", "\u200e", "&rlm;", "\u200f")
+)
+
+// assToHTML translates the SubStation Alpha markup to the SRT one. Italics, bold and underline are
+// kept as tags and every other override tag is dropped, as is the text of vector drawings.
+func assToHTML(text string) string {
+	var sb strings.Builder
+	var open []string
+	drawing := false
+
+	setTag := func(tag string, enabled bool) {
+		idx := -1
+		for i, t := range open {
+			if t == tag {
+				idx = i
+			}
+		}
+		if enabled && idx == -1 {
+			open = append(open, tag)
+			sb.WriteString("<" + tag + ">")
+		} else if !enabled && idx != -1 {
+			open = append(open[:idx], open[idx+1:]...)
+			sb.WriteString("</" + tag + ">")
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c == '{' {
+			end := strings.IndexByte(text[i:], '}')
+			if end != -1 {
+				for _, tag := range strings.Split(text[i+1:i+end], `\`)[1:] {
+					switch {
+					case tag == "i1", tag == "i0", tag == "i":
+						setTag("i", tag == "i1")
+					case tag == "u1", tag == "u0", tag == "u":
+						setTag("u", tag == "u1")
+					case tag == "b0", tag == "b":
+						setTag("b", false)
+					case strings.HasPrefix(tag, "b") && len(tag) > 1 && tag[1] >= '1' && tag[1] <= '9':
+						// \b1 or a font weight like \b700
+						setTag("b", tag == "b1" || len(tag) >= 4 && tag[1] >= '6')
+					case strings.HasPrefix(tag, "p") && len(tag) > 1 && tag[1] >= '0' && tag[1] <= '9':
+						drawing = tag != "p0"
+					case strings.HasPrefix(tag, "r"):
+						for len(open) > 0 {
+							setTag(open[len(open)-1], false)
+						}
+					}
+				}
+				i += end
+				continue
+			}
+		}
+
+		if c == '\\' && i+1 < len(text) {
+			switch text[i+1] {
+			case 'N', 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case 'h':
+				sb.WriteString(" ")
+				i++
+				continue
+			}
+		}
+
+		if !drawing {
+			sb.WriteByte(c)
+		}
+	}
+
+	for len(open) > 0 {
+		setTag(open[len(open)-1], false)
+	}
+
+	return trimLines(sb.String())
+}
+
+// htmlToASS translates the SRT markup to override tags. Font colours are kept, other tags are dropped.
+func htmlToASS(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range htmlTagPattern.FindAllStringIndex(text, -1) {
+		sb.WriteString(escapeASS(text[last:loc[0]]))
+		sb.WriteString(htmlTagToASS(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	sb.WriteString(escapeASS(text[last:]))
+
+	return strings.ReplaceAll(sb.String(), "\n", `\N`)
+}
+
+// htmlTagToASS returns the override tag of an SRT tag, or nothing.
+func htmlTagToASS(tag string) string {
+	closing := strings.HasPrefix(tag, "</")
+	name := strings.ToLower(strings.Trim(strings.Fields(strings.Trim(tag, "</>"))[0], "/"))
+
+	switch name {
+	case "i", "b", "u", "s":
+		if closing {
+			return `{\` + name + `0}`
+		}
+		return `{\` + name + `1}`
+	case "font":
+		if closing {
+			return `{\c}`
+		}
+		if m := fontColorPattern.FindStringSubmatch(tag); m != nil {
+			rgb := strings.ToUpper(m[1])
+			return fmt.Sprintf(`{\c&H%s%s%s&}`, rgb[4:6], rgb[2:4], rgb[0:2])
+		}
+	}
+	return ""
+}
+
+// escapeASS keeps the text of an SRT line from being read as override tags. ASS has no escape for
+// braces, so they become parentheses, and a backslash that would start a line break, a hard space
+// or an escaped brace of the next override tag is dropped.
+func escapeASS(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '{':
+			sb.WriteByte('(')
+		case '}':
+			sb.WriteByte(')')
+		case '\\':
+			if i+1 == len(text) || strings.IndexByte("Nnh", text[i+1]) != -1 {
+				continue
+			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// webVTTToHTML keeps the italics, bold and underline tags of a WebVTT cue and drops classes,
+// voices, ruby and inline timestamps.
+func webVTTToHTML(text string) string {
+	converted := webVTTTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		m := webVTTTagPattern.FindStringSubmatch(tag)
+		switch m[2] {
+		case "i", "b", "u":
+			return "<" + m[1] + m[2] + ">"
+		}
+		return ""
+	})
+	return webVTTEntities.Replace(converted)
+}
+
+// htmlToWebVTT escapes the characters WebVTT reserves and drops the tags it doesn't support.
+func htmlToWebVTT(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range htmlTagPattern.FindAllStringIndex(text, -1) {
+		sb.WriteString(escapeWebVTT(text[last:loc[0]]))
+		tag := strings.ToLower(text[loc[0]:loc[1]])
+		switch tag {
+		case "<i>", "</i>", "<b>", "</b>", "<u>", "</u>":
+			sb.WriteString(tag)
+		}
+		last = loc[1]
+	}
+	sb.WriteString(escapeWebVTT(text[last:]))
+	return sb.String()
+}
+
+func escapeWebVTT(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func stripHTML(text string) string {
+	return htmlTagPattern.ReplaceAllString(text, "")
+}
+
+// trimLines removes the spaces around each line and the empty lines left after dropping tags.
+func trimLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,112 @@
+package subtitles
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"time"
+)
+
+// PGS segment types
+const (
+	pgsCompositionSegment = 0x16
+)
+
+// PGS composition object flags
+const (
+	pgsObjectCropped = 0x80
+	pgsObjectForced  = 0x40
+)
+
+// Caption is a bitmap shown by a PGS subtitle stream.
+type Caption struct {
+	Start  time.Duration
+	End    time.Duration
+	Forced bool // Every object of the caption is flagged to be shown even when subtitles are off
+}
+
+var errTruncatedPGS = errors.New("truncated PGS stream")
+
+// ReadPGS reads the captions of a PGS (.sup) file, see ParsePGS.
+func ReadPGS(path string) ([]Caption, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePGS(data)
+}
+
+// ParsePGS returns the captions of a PGS stream as written by mkvextract. Each caption starts at a
+// presentation composition with objects and ends at the next composition, which either clears
+// the screen or shows a new caption. Palette updates don't change the caption.
+func ParsePGS(data []byte) ([]Caption, error) {
+	var captions []Caption
+	open := false
+	for len(data) > 0 {
+		if len(data) < 13 || string(data[:2]) != "PG" {
+			return nil, errTruncatedPGS
+		}
+		pts := time.Duration(binary.BigEndian.Uint32(data[2:])) * time.Second / 90000
+		segmentType := data[10]
+		size := int(binary.BigEndian.Uint16(data[11:]))
+		if len(data) < 13+size {
+			return nil, errTruncatedPGS
+		}
+		segment := data[13 : 13+size]
+		data = data[13+size:]
+
+		if segmentType != pgsCompositionSegment {
+			continue
+		}
+		if len(segment) < 11 {
+			return nil, errTruncatedPGS
+		}
+		paletteUpdate := segment[8]&0x80 != 0
+		objects := int(segment[10])
+		if paletteUpdate && objects > 0 {
+			continue
+		}
+
+		if open {
+			if last := &captions[len(captions)-1]; pts > last.Start {
+				last.End = pts
+			} else {
+				captions = captions[:len(captions)-1]
+			}
+			open = false
+		}
+		if objects == 0 {
+			continue
+		}
+
+		forced := true
+		for i, pos := 0, 11; i < objects; i++ {
+			if pos+8 > len(segment) {
+				return nil, errTruncatedPGS
+			}
+			flags := segment[pos+3]
+			forced = forced && flags&pgsObjectForced != 0
+			pos += 8
+			if flags&pgsObjectCropped != 0 {
+				pos += 8
+			}
+		}
+		captions = append(captions, Caption{Start: pts, Forced: forced})
+		open = true
+	}
+
+	// A caption that is never cleared has no known end
+	if open {
+		captions = captions[:len(captions)-1]
+	}
+	return captions, nil
+}
+
+// CaptionCoverage returns the time with some caption on screen.
+func CaptionCoverage(captions []Caption) time.Duration {
+	events := make([]Event, len(captions))
+	for i, c := range captions {
+		events[i] = Event{Start: c.Start, End: c.End}
+	}
+	return coverage(events)
+}
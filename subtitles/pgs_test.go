@@ -0,0 +1,74 @@
+package subtitles
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// pgsComposition encodes a presentation composition segment at the given time in milliseconds with
+// an object per forced flag.
+func pgsComposition(ms int, paletteUpdate bool, forced ...bool) []byte {
+	payload := []byte{0x07, 0x80, 0x04, 0x38, 0x10, 0, 1, 0x80, 0, 0, byte(len(forced))}
+	if paletteUpdate {
+		payload[8] = 0x80
+	}
+	for i, f := range forced {
+		flags := byte(0)
+		if f {
+			flags = pgsObjectForced
+		}
+		payload = append(payload, 0, byte(i), 0, flags, 0, 10, 0, 10)
+	}
+	return pgsSegment(ms, pgsCompositionSegment, payload)
+}
+
+func pgsSegment(ms int, segmentType byte, payload []byte) []byte {
+	segment := []byte("PG")
+	segment = binary.BigEndian.AppendUint32(segment, uint32(ms*90))
+	segment = binary.BigEndian.AppendUint32(segment, 0)
+	segment = append(segment, segmentType)
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)))
+	return append(segment, payload...)
+}
+
+func TestParsePGS(t *testing.T) {
+	var data []byte
+	for _, segment := range [][]byte{
+		pgsComposition(1000, false, false),
+		pgsSegment(1000, 0x80, nil), // end of display set
+		pgsComposition(1500, true, false),
+		pgsComposition(3000, false),
+		pgsComposition(4000, false, true, true),
+		pgsComposition(6000, false, true, false),
+		pgsComposition(7000, false),
+		pgsComposition(9000, false, false),
+	} {
+		data = append(data, segment...)
+	}
+
+	captions, err := ParsePGS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Caption{
+		{Start: time.Second, End: 3 * time.Second},
+		{Start: 4 * time.Second, End: 6 * time.Second, Forced: true},
+		{Start: 6 * time.Second, End: 7 * time.Second},
+	}
+	if len(captions) != len(expected) {
+		t.Fatalf("expected %d captions, got %+v", len(expected), captions)
+	}
+	for i := range expected {
+		if captions[i] != expected[i] {
+			t.Errorf("caption %d: expected %+v, got %+v", i, expected[i], captions[i])
+		}
+	}
+	if coverage := CaptionCoverage(captions); coverage != 5*time.Second {
+		t.Errorf("expected 5s of coverage, got %v", coverage)
+	}
+
+	if _, err := ParsePGS(data[:len(data)-3]); err == nil {
+		t.Error("expected an error for a truncated stream")
+	}
+}
@@ -0,0 +1,88 @@
+package subtitles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func parseSRT(text string) (*Subtitle, error) {
+	sub := &Subtitle{Format: FormatSRT}
+
+	for _, block := range splitBlocks(text) {
+		lines := strings.Split(block, "\n")
+		// The counter line is optional in practice, the timing line is not
+		if _, err := strconv.Atoi(strings.TrimSpace(lines[0])); err == nil && len(lines) > 1 {
+			lines = lines[1:]
+		}
+
+		if !strings.Contains(lines[0], "-->") {
+			// Stray text without timing can't be shown, skip it like players do
+			continue
+		}
+
+		start, end, _, err := parseTiming(lines[0])
+		if err != nil {
+			return nil, fmt.Errorf("subtitle %d: %v", len(sub.Events)+1, err)
+		}
+
+		sub.Events = append(sub.Events, Event{
+			Start: start,
+			End:   end,
+			Text:  strings.Join(lines[1:], "\n"),
+		})
+	}
+
+	return sub, nil
+}
+
+func writeSRT(s *Subtitle) []byte {
+	var sb strings.Builder
+	for i, e := range s.sortedEvents() {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatSRTTimestamp(e.Start), formatSRTTimestamp(e.End), e.Text)
+	}
+	return []byte(sb.String())
+}
+
+// splitBlocks splits SRT and WebVTT files into their blank line separated blocks.
+func splitBlocks(text string) []string {
+	var blocks []string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, strings.TrimRight(line, " \t"))
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// parseTiming reads a "start --> end [settings]" line, returning the settings that follow the end
+// timestamp in WebVTT cues.
+func parseTiming(line string) (start, end time.Duration, settings string, err error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, "", fmt.Errorf("invalid timing line: %s", line)
+	}
+
+	endFields := strings.Fields(parts[1])
+	if len(endFields) == 0 {
+		return 0, 0, "", fmt.Errorf("invalid timing line: %s", line)
+	}
+
+	if start, err = ParseTimestamp(parts[0]); err != nil {
+		return 0, 0, "", err
+	}
+	if end, err = ParseTimestamp(endFields[0]); err != nil {
+		return 0, 0, "", err
+	}
+	return start, end, strings.Join(endFields[1:], " "), nil
+}
@@ -0,0 +1,203 @@
+package subtitles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style is a SubStation Alpha v4+ style. Colours keep the &HAABBGGRR notation of the format.
+type Style struct {
+	Name            string  `json:"name"`
+	Fontname        string  `json:"fontname"`
+	Fontsize        float64 `json:"fontsize"`
+	PrimaryColour   string  `json:"primary_colour"`
+	SecondaryColour string  `json:"secondary_colour"`
+	OutlineColour   string  `json:"outline_colour"`
+	BackColour      string  `json:"back_colour"`
+	Bold            bool    `json:"bold"`
+	Italic          bool    `json:"italic"`
+	Underline       bool    `json:"underline"`
+	StrikeOut       bool    `json:"strike_out"`
+	ScaleX          float64 `json:"scale_x"`
+	ScaleY          float64 `json:"scale_y"`
+	Spacing         float64 `json:"spacing"`
+	Angle           float64 `json:"angle"`
+	BorderStyle     int     `json:"border_style"`
+	Outline         float64 `json:"outline"`
+	Shadow          float64 `json:"shadow"`
+	Alignment       int     `json:"alignment"` // Numpad layout, 2 is bottom center
+	MarginL         int     `json:"margin_l"`
+	MarginR         int     `json:"margin_r"`
+	MarginV         int     `json:"margin_v"`
+	Encoding        int     `json:"encoding"`
+}
+
+// DefaultStyle is the style used when converting SRT or WebVTT to ASS, sized for a 1080p script
+// resolution.
+func DefaultStyle() Style {
+	return Style{
+		Name:            "Default",
+		Fontname:        "Arial",
+		Fontsize:        72,
+		PrimaryColour:   "&H00FFFFFF",
+		SecondaryColour: "&H000000FF",
+		OutlineColour:   "&H00000000",
+		BackColour:      "&H80000000",
+		ScaleX:          100,
+		ScaleY:          100,
+		BorderStyle:     1,
+		Outline:         3.5,
+		Shadow:          1.5,
+		Alignment:       2,
+		MarginL:         60,
+		MarginR:         60,
+		MarginV:         50,
+		Encoding:        1,
+	}
+}
+
+func defaultScriptInfo() []InfoField {
+	return []InfoField{
+		{"ScriptType", "v4.00+"},
+		{"WrapStyle", "0"},
+		{"ScaledBorderAndShadow", "yes"},
+		{"PlayResX", "1920"},
+		{"PlayResY", "1080"},
+	}
+}
+
+const styleFormat = "Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding"
+
+// parseStyle reads the fields of a Style line following the section format. SSA v4 styles are
+// converted to v4+: the tertiary colour becomes the outline colour and the alignment is remapped.
+func parseStyle(format []string, value string, legacy bool) Style {
+	style := Style{ScaleX: 100, ScaleY: 100, BorderStyle: 1, Alignment: 2}
+	fields := strings.SplitN(value, ",", len(format))
+
+	for i, name := range format {
+		if i >= len(fields) {
+			break
+		}
+		field := strings.TrimSpace(fields[i])
+		switch name {
+		case "name":
+			style.Name = field
+		case "fontname":
+			style.Fontname = field
+		case "fontsize":
+			style.Fontsize = parseFloat(field)
+		case "primarycolour":
+			style.PrimaryColour = parseColour(field)
+		case "secondarycolour":
+			style.SecondaryColour = parseColour(field)
+		case "outlinecolour", "tertiarycolour":
+			style.OutlineColour = parseColour(field)
+		case "backcolour":
+			style.BackColour = parseColour(field)
+		case "bold":
+			style.Bold = field != "0"
+		case "italic":
+			style.Italic = field != "0"
+		case "underline":
+			style.Underline = field != "0"
+		case "strikeout":
+			style.StrikeOut = field != "0"
+		case "scalex":
+			style.ScaleX = parseFloat(field)
+		case "scaley":
+			style.ScaleY = parseFloat(field)
+		case "spacing":
+			style.Spacing = parseFloat(field)
+		case "angle":
+			style.Angle = parseFloat(field)
+		case "borderstyle":
+			style.BorderStyle, _ = strconv.Atoi(field)
+		case "outline":
+			style.Outline = parseFloat(field)
+		case "shadow":
+			style.Shadow = parseFloat(field)
+		case "alignment":
+			style.Alignment, _ = strconv.Atoi(field)
+			if legacy {
+				style.Alignment = legacyAlignment(style.Alignment)
+			}
+		case "marginl":
+			style.MarginL, _ = strconv.Atoi(field)
+		case "marginr":
+			style.MarginR, _ = strconv.Atoi(field)
+		case "marginv":
+			style.MarginV, _ = strconv.Atoi(field)
+		case "encoding":
+			style.Encoding, _ = strconv.Atoi(field)
+		}
+	}
+
+	return style
+}
+
+func (st *Style) line() string {
+	return strings.Join([]string{
+		st.Name,
+		st.Fontname,
+		formatFloat(st.Fontsize),
+		st.PrimaryColour,
+		st.SecondaryColour,
+		st.OutlineColour,
+		st.BackColour,
+		formatBool(st.Bold),
+		formatBool(st.Italic),
+		formatBool(st.Underline),
+		formatBool(st.StrikeOut),
+		formatFloat(st.ScaleX),
+		formatFloat(st.ScaleY),
+		formatFloat(st.Spacing),
+		formatFloat(st.Angle),
+		strconv.Itoa(st.BorderStyle),
+		formatFloat(st.Outline),
+		formatFloat(st.Shadow),
+		strconv.Itoa(st.Alignment),
+		strconv.Itoa(st.MarginL),
+		strconv.Itoa(st.MarginR),
+		strconv.Itoa(st.MarginV),
+		strconv.Itoa(st.Encoding),
+	}, ",")
+}
+
+// legacyAlignment converts the SSA alignment (1-3 bottom, 5-7 top, 9-11 middle) to the numpad layout.
+func legacyAlignment(a int) int {
+	switch {
+	case a >= 9:
+		return a - 5
+	case a >= 5:
+		return a + 2
+	}
+	return a
+}
+
+// parseColour accepts both &HAABBGGRR and the decimal colours of SSA files.
+func parseColour(value string) string {
+	if strings.HasPrefix(strings.ToUpper(value), "&H") {
+		return strings.ToUpper(value)
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return fmt.Sprintf("&H%08X", uint32(n))
+	}
+	return value
+}
+
+func parseFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "-1"
+	}
+	return "0"
+}
@@ -0,0 +1,302 @@
+package subtitles
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"videorepack/mkv"
+)
+
+type Format string
+
+const (
+	FormatSRT    Format = "srt"
+	FormatASS    Format = "ass"
+	FormatSSA    Format = "ssa"
+	FormatWebVTT Format = "vtt"
+)
+
+var formatCodecIDs = map[Format]string{
+	FormatSRT:    "S_TEXT/UTF8",
+	FormatASS:    "S_TEXT/ASS",
+	FormatSSA:    "S_TEXT/SSA",
+	FormatWebVTT: "S_TEXT/WEBVTT",
+}
+
+// CodecID returns the Matroska codec ID of the format.
+func (f Format) CodecID() string {
+	return formatCodecIDs[f]
+}
+
+// FormatFromCodecID returns the format of a Matroska text subtitle codec, including the legacy IDs
+// such as S_TEXT/ASCII or S_ASS, from the extension mkvextract gives them.
+func FormatFromCodecID(codecID string) (Format, bool) {
+	codec, ok := mkv.Codecs.Lookup(codecID)
+	if !ok || codec.Class != mkv.CodecText {
+		return "", false
+	}
+	f := Format(codec.Extension)
+	if _, ok := formatCodecIDs[f]; !ok {
+		return "", false
+	}
+	return f, true
+}
+
+// FormatFromPath returns the format matching the file extension.
+func FormatFromPath(path string) (Format, bool) {
+	f := Format(strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")))
+	_, ok := formatCodecIDs[f]
+	return f, ok
+}
+
+// styled reports whether the format uses the SubStation Alpha markup instead of the HTML-like
+// tags of SRT and WebVTT.
+func (f Format) styled() bool {
+	return f == FormatASS || f == FormatSSA
+}
+
+type Event struct {
+	Start time.Duration
+	End   time.Duration
+	// Text in the markup of the subtitle format, lines are separated by "\n" in SRT and WebVTT
+	// and by "\N" in ASS
+	Text string
+
+	// SubStation Alpha fields
+	Comment bool
+	Layer   int
+	Style   string
+	Actor   string
+	MarginL int
+	MarginR int
+	MarginV int
+	Effect  string
+}
+
+type InfoField struct {
+	Key   string
+	Value string
+}
+
+// Section is a SubStation Alpha section kept as is, such as [Fonts] or [Aegisub Project Garbage].
+type Section struct {
+	Name  string
+	Lines []string
+}
+
+type Subtitle struct {
+	Format Format
+	// SubStation Alpha header, styles and sections
+	ScriptInfo    []InfoField
+	Styles        []Style
+	ExtraSections []Section
+
+	Events []Event
+}
+
+// Parse reads UTF-8 subtitle data in the given format.
+func Parse(data []byte, format Format) (*Subtitle, error) {
+	text := string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	switch format {
+	case FormatSRT:
+		return parseSRT(text)
+	case FormatASS, FormatSSA:
+		return parseASS(text, format)
+	case FormatWebVTT:
+		return parseWebVTT(text)
+	}
+	return nil, fmt.Errorf("unsupported subtitle format: %s", format)
+}
+
+// ParseFile reads a subtitle file, guessing the format from its extension. Files that aren't UTF-8
+// are decoded first, see DetectEncoding, assuming Windows-1252 for ambiguous 8-bit data; convert
+// them beforehand to use another charset.
+func ParseFile(path string) (*Subtitle, error) {
+	format, ok := FormatFromPath(path)
+	if !ok {
+		return nil, fmt.Errorf("unknown subtitle format: %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if enc := DetectEncoding(data, "", "windows-1252"); enc != EncodingUTF8 {
+		if data, err = DecodeToUTF8(data, enc); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+	}
+
+	sub, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return sub, nil
+}
+
+// Bytes writes the subtitle in its format.
+func (s *Subtitle) Bytes() []byte {
+	switch s.Format {
+	case FormatASS, FormatSSA:
+		return writeASS(s)
+	case FormatWebVTT:
+		return writeWebVTT(s)
+	}
+	return writeSRT(s)
+}
+
+func (s *Subtitle) WriteFile(path string) error {
+	return os.WriteFile(path, s.Bytes(), 0644)
+}
+
+// ConvertTo changes the format of the subtitle, translating the markup of every event. Converting
+// to SRT or WebVTT drops the styles and keeps only italics, bold and underline; converting to ASS
+// uses defaultStyle for every event.
+func (s *Subtitle) ConvertTo(format Format, defaultStyle Style) {
+	from := s.Format
+	s.Format = format
+	if format == FormatSSA {
+		// SSA is always written with the v4+ styles, which makes it an ASS file
+		s.Format = FormatASS
+	}
+
+	if from.styled() == format.styled() {
+		if from == FormatWebVTT && format == FormatSRT {
+			for i := range s.Events {
+				s.Events[i].Text = webVTTToHTML(s.Events[i].Text)
+			}
+		} else if from == FormatSRT && format == FormatWebVTT {
+			for i := range s.Events {
+				s.Events[i].Text = htmlToWebVTT(s.Events[i].Text)
+			}
+		}
+		return
+	}
+
+	if format.styled() {
+		if defaultStyle.Name == "" {
+			defaultStyle.Name = "Default"
+		}
+		s.Styles = []Style{defaultStyle}
+		s.ScriptInfo = defaultScriptInfo()
+		for i := range s.Events {
+			e := &s.Events[i]
+			text := e.Text
+			if from == FormatWebVTT {
+				text = webVTTToHTML(text)
+			}
+			e.Text = htmlToASS(text)
+			e.Style = defaultStyle.Name
+		}
+		return
+	}
+
+	events := make([]Event, 0, len(s.Events))
+	for _, e := range s.Events {
+		if e.Comment {
+			continue
+		}
+		text := assToHTML(e.Text)
+		if strings.TrimSpace(stripHTML(text)) == "" {
+			// Vector drawings and empty sign lines have nothing to show as plain text
+			continue
+		}
+		if format == FormatWebVTT {
+			text = htmlToWebVTT(text)
+		}
+		events = append(events, Event{Start: e.Start, End: e.End, Text: text})
+	}
+	s.Events = events
+	s.Styles = nil
+	s.ScriptInfo = nil
+	s.ExtraSections = nil
+}
+
+// Retime maps the timestamps of every event to t*factor + offset, a factor of 0 keeps the speed.
+// Events that end before the start of the video are removed and those that start before it are cut.
+func (s *Subtitle) Retime(factor float64, offset time.Duration) {
+	if factor == 0 {
+		factor = 1
+	}
+
+	events := s.Events[:0]
+	for _, e := range s.Events {
+		e.Start = time.Duration(float64(e.Start)*factor) + offset
+		e.End = time.Duration(float64(e.End)*factor) + offset
+		if e.End <= 0 {
+			continue
+		}
+		e.Start = max(e.Start, 0)
+		events = append(events, e)
+	}
+	s.Events = events
+}
+
+// PlainText returns the text of an event without any markup, with lines separated by "\n".
+func (s *Subtitle) PlainText(e Event) string {
+	return PlainText(s.Format, e.Text)
+}
+
+// PlainText removes the markup of a subtitle text in the given format.
+func PlainText(format Format, text string) string {
+	switch format {
+	case FormatASS, FormatSSA:
+		return stripHTML(assToHTML(text))
+	case FormatWebVTT:
+		return stripHTML(webVTTToHTML(text))
+	}
+	return stripHTML(text)
+}
+
+// Dialogue returns the events shown on screen: comments and events without text, such as vector
+// drawings, are skipped.
+func (s *Subtitle) Dialogue() []Event {
+	var events []Event
+	for _, e := range s.Events {
+		if !e.Comment && e.End > e.Start && strings.TrimSpace(s.PlainText(e)) != "" {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Coverage returns the time with some dialogue on screen. Overlapping events are counted once.
+func (s *Subtitle) Coverage() time.Duration {
+	return coverage(s.Dialogue())
+}
+
+func coverage(events []Event) time.Duration {
+	slices.SortFunc(events, func(a, b Event) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	var total time.Duration
+	var start, end time.Duration
+	for i, e := range events {
+		if i > 0 && e.Start <= end {
+			end = max(end, e.End)
+			continue
+		}
+		total += end - start
+		start, end = e.Start, e.End
+	}
+	return total + end - start
+}
+
+// sortedEvents returns the events ordered by start time, as SRT and WebVTT players expect.
+func (s *Subtitle) sortedEvents() []Event {
+	events := slices.Clone(s.Events)
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	return events
+}
@@ -0,0 +1,359 @@
+package subtitles
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const srtSample = "\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:03,500\r\n<i>Hola,</i> ¿qué tal?\r\nBien & tú\r\n\r\n2\r\n00:01:02,050 --> 00:01:04,000\r\n<font color=\"#FF8000\">Naranja</font>\r\n"
+
+const assSample = `[Script Info]
+; Script generated by Aegisub
+Title: Sample
+ScriptType: v4.00+
+PlayResX: 1920
+PlayResY: 1080
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Open Sans Semibold,72,&H00FFFFFF,&H000000FF,&H00020713,&H00000000,-1,0,0,0,100,100,0,0,1,3.6,1.5,2,200,200,60,1
+Style: Sign,Arial,48,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,0,8,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:03.50,Default,Rudeus,0,0,0,,{\i1}Hola,{\i0} ¿qué tal?\NBien, gracias
+Comment: 0,0:00:02.00,0:00:03.00,Default,,0,0,0,,Translator note
+Dialogue: 1,0:00:04.00,0:00:06.00,Sign,,0,0,0,,{\pos(960,100)\fnArial\b1}Tienda{\b0}
+Dialogue: 2,0:00:04.00,0:00:06.00,Sign,,0,0,0,,{\p1}m 0 0 l 100 0 100 100 0 100{\p0}
+
+[Aegisub Project Garbage]
+Active Line: 3
+`
+
+const webVTTSample = `WEBVTT
+Kind: captions
+
+NOTE generated by a tool
+
+intro
+00:01.000 --> 00:03.500 align:start line:0
+<v Rudeus><i.yellow>Hola</i> &amp; adiós</v>
+
+00:00:04.000 --> 00:00:06.000
+Segunda <00:00:05.000>línea
+`
+
+func TestFormatFromCodecID(t *testing.T) {
+	for codecID, want := range map[string]Format{
+		"S_TEXT/UTF8":   FormatSRT,
+		"S_TEXT/ASCII":  FormatSRT,
+		"S_TEXT/ASS":    FormatASS,
+		"S_ASS":         FormatASS,
+		"S_SSA":         FormatSSA,
+		"S_TEXT/WEBVTT": FormatWebVTT,
+		"S_TEXT/USF":    "",
+		"S_HDMV/PGS":    "",
+	} {
+		if got, ok := FormatFromCodecID(codecID); got != want || ok != (want != "") {
+			t.Errorf("%s: expected %q, got %q", codecID, want, got)
+		}
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	sub, err := Parse([]byte(srtSample), FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sub.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(sub.Events))
+	}
+	first := sub.Events[0]
+	if first.Start != time.Second || first.End != 3500*time.Millisecond || first.Text != "<i>Hola,</i> ¿qué tal?\nBien & tú" {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+	if sub.Events[1].Start != time.Minute+2050*time.Millisecond {
+		t.Errorf("unexpected start %v", sub.Events[1].Start)
+	}
+
+	want := "1\n00:00:01,000 --> 00:00:03,500\n<i>Hola,</i> ¿qué tal?\nBien & tú\n\n2\n00:01:02,050 --> 00:01:04,000\n<font color=\"#FF8000\">Naranja</font>\n\n"
+	if got := string(sub.Bytes()); got != want {
+		t.Errorf("unexpected SRT output:\n%s", got)
+	}
+}
+
+func TestParseASS(t *testing.T) {
+	sub, err := Parse([]byte(assSample), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sub.Styles) != 2 || sub.Styles[0].Fontname != "Open Sans Semibold" || !sub.Styles[0].Bold || sub.Styles[1].Alignment != 8 {
+		t.Errorf("unexpected styles: %+v", sub.Styles)
+	}
+	if len(sub.Events) != 4 || !sub.Events[1].Comment || sub.Events[0].Actor != "Rudeus" {
+		t.Fatalf("unexpected events: %+v", sub.Events)
+	}
+	if text := sub.Events[0].Text; text != `{\i1}Hola,{\i0} ¿qué tal?\NBien, gracias` {
+		t.Errorf("unexpected text with commas: %q", text)
+	}
+	if len(sub.ExtraSections) != 1 || sub.ExtraSections[0].Lines[0] != "Active Line: 3" {
+		t.Errorf("unexpected extra sections: %+v", sub.ExtraSections)
+	}
+
+	// Writing and parsing again keeps every event
+	again, err := Parse(sub.Bytes(), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Events) != len(sub.Events) || again.Events[2] != sub.Events[2] || again.Styles[0] != sub.Styles[0] {
+		t.Errorf("ASS round trip changed the content:\n%s", again.Bytes())
+	}
+}
+
+func TestParseASSEmbeddedFonts(t *testing.T) {
+	ass := "[Script Info]\nScriptType: v4.00+\n\n[Fonts]\nfontname: Sign.ttf\n[&E.=\\0\"!:]\n" +
+		"M)[X0%!H!@\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Hola\n"
+	sub, err := Parse([]byte(ass), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sub.ExtraSections) != 1 || len(sub.ExtraSections[0].Lines) != 3 || sub.ExtraSections[0].Lines[1] != `[&E.=\0"!:]` {
+		t.Errorf("unexpected extra sections: %+v", sub.ExtraSections)
+	}
+	if len(sub.Events) != 1 {
+		t.Errorf("unexpected events: %+v", sub.Events)
+	}
+}
+
+func TestParseSSA(t *testing.T) {
+	ssa := `[Script Info]
+ScriptType: v4.00
+
+[V4 Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, TertiaryColour, BackColour, Bold, Italic, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, AlphaLevel, Encoding
+Style: Default,Tahoma,24,16777215,65535,65535,-2147483640,-1,0,1,1,2,6,30,30,10,0,0
+
+[Events]
+Format: Marked, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: Marked=0,0:00:01.20,0:00:02.00,Default,,0000,0000,0000,,Hola
+`
+	sub, err := Parse([]byte(ssa), FormatSSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	style := sub.Styles[0]
+	if style.PrimaryColour != "&H00FFFFFF" || style.OutlineColour != "&H0000FFFF" || style.Alignment != 8 {
+		t.Errorf("SSA style not converted to v4+: %+v", style)
+	}
+	if sub.Events[0].Start != 1200*time.Millisecond || sub.Events[0].Text != "Hola" {
+		t.Errorf("unexpected event: %+v", sub.Events[0])
+	}
+	if out := string(sub.Bytes()); !strings.Contains(out, "[V4+ Styles]") || !strings.Contains(out, "ScriptType: v4.00+") {
+		t.Errorf("SSA not written as v4+:\n%s", out)
+	}
+}
+
+func TestParseWebVTT(t *testing.T) {
+	sub, err := Parse([]byte(webVTTSample), FormatWebVTT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sub.Events) != 2 || sub.Events[0].Start != time.Second || sub.Events[1].End != 6*time.Second {
+		t.Fatalf("unexpected events: %+v", sub.Events)
+	}
+
+	sub.ConvertTo(FormatSRT, DefaultStyle())
+	if text := sub.Events[0].Text; text != "<i>Hola</i> & adiós" {
+		t.Errorf("unexpected converted cue: %q", text)
+	}
+	if text := sub.Events[1].Text; text != "Segunda línea" {
+		t.Errorf("inline timestamp not removed: %q", text)
+	}
+
+	sub.ConvertTo(FormatWebVTT, DefaultStyle())
+	if !strings.Contains(string(sub.Bytes()), "00:00:01.000 --> 00:00:03.500\n<i>Hola</i> &amp; adiós\n") {
+		t.Errorf("unexpected WebVTT output:\n%s", sub.Bytes())
+	}
+}
+
+func TestConvertASSToSRT(t *testing.T) {
+	sub, err := Parse([]byte(assSample), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub.ConvertTo(FormatSRT, DefaultStyle())
+	if sub.Format.CodecID() != "S_TEXT/UTF8" {
+		t.Errorf("unexpected codec %s", sub.Format.CodecID())
+	}
+
+	// The comment and the vector drawing are dropped, override tags are stripped
+	want := "1\n00:00:01,000 --> 00:00:03,500\n<i>Hola,</i> ¿qué tal?\nBien, gracias\n\n" +
+		"2\n00:00:04,000 --> 00:00:06,000\n<b>Tienda</b>\n\n"
+	if got := string(sub.Bytes()); got != want {
+		t.Errorf("unexpected SRT output:\n%s", got)
+	}
+}
+
+func TestConvertSRTToASS(t *testing.T) {
+	sub, err := Parse([]byte(srtSample), FormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	style := DefaultStyle()
+	style.Name = "Main"
+	style.Fontname = "Roboto"
+	sub.ConvertTo(FormatASS, style)
+
+	out := string(sub.Bytes())
+	for _, want := range []string{
+		"Style: Main,Roboto,72,",
+		`Dialogue: 0,0:00:01.00,0:00:03.50,Main,,0,0,0,,{\i1}Hola,{\i0} ¿qué tal?\NBien & tú`,
+		`Dialogue: 0,0:01:02.05,0:01:04.00,Main,,0,0,0,,{\c&H0080FF&}Naranja{\c}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in ASS output:\n%s", want, out)
+		}
+	}
+}
+
+func TestSRTToASSEscapes(t *testing.T) {
+	for text, want := range map[string]string{
+		"{Susurra} hola":        "(Susurra) hola",
+		`C:\new<i>\</i>`:        `C:new{\i1}{\i0}`,
+		"a \\ b\nc":             `a \ b\Nc`,
+		"<i>{\\an8}</i> cartel": `{\i1}(\an8){\i0} cartel`,
+	} {
+		if got := htmlToASS(text); got != want {
+			t.Errorf("htmlToASS(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	if got := PlainText(FormatASS, `{\an8\i1}[Música]{\i0}\Nhola`); got != "[Música]\nhola" {
+		t.Errorf("unexpected ASS plain text: %q", got)
+	}
+	if got := PlainText(FormatSRT, "<i>♪ la la</i>"); got != "♪ la la" {
+		t.Errorf("unexpected SRT plain text: %q", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	sub := &Subtitle{Format: FormatASS, Events: []Event{
+		{Start: 1 * time.Second, End: 3 * time.Second, Text: "uno"},
+		{Start: 2 * time.Second, End: 4 * time.Second, Text: "dos"},
+		{Start: 10 * time.Second, End: 11 * time.Second, Text: "tres"},
+		{Start: 20 * time.Second, End: 30 * time.Second, Text: "nota", Comment: true},
+		{Start: 40 * time.Second, End: 50 * time.Second, Text: `{\p1}m 0 0 l 10 0{\p0}`},
+	}}
+
+	if got := len(sub.Dialogue()); got != 3 {
+		t.Errorf("expected 3 dialogue events, got %d", got)
+	}
+	if got := sub.Coverage(); got != 4*time.Second {
+		t.Errorf("expected 4s of coverage, got %v", got)
+	}
+}
+
+func TestStripHearingImpaired(t *testing.T) {
+	sub := &Subtitle{Format: FormatSRT, Events: []Event{
+		{Start: 1 * time.Second, End: 2 * time.Second, Text: "[PUERTA SE CIERRA]"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: "- JUAN: ¿Quién es?\n- (SUSPIRA) Nadie."},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "<i>[music playing]</i>\n- MARÍA: Vete."},
+		{Start: 4 * time.Second, End: 5 * time.Second, Text: "♪ La la la ♪"},
+		{Start: 5 * time.Second, End: 6 * time.Second, Text: "Dijo (en voz baja) que no - nunca."},
+	}}
+
+	if cues := sub.FindHearingCues(sub.Events[1]); !cues.Descriptions || !cues.Speakers || cues.Music {
+		t.Errorf("unexpected cues: %+v", cues)
+	}
+	if cues := sub.FindHearingCues(sub.Events[4]); cues.Any() {
+		t.Errorf("unexpected cues in normal text: %+v", cues)
+	}
+
+	if changed := sub.StripHearingImpaired(); changed != 4 {
+		t.Errorf("expected 4 changed events, got %d", changed)
+	}
+	want := []string{"- ¿Quién es?\n- Nadie.", "Vete.", "La la la", "Dijo (en voz baja) que no - nunca."}
+	if len(sub.Events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(sub.Events))
+	}
+	for i, text := range want {
+		if sub.Events[i].Text != text {
+			t.Errorf("event %d: expected %q, got %q", i, text, sub.Events[i].Text)
+		}
+	}
+}
+
+func TestStripHearingImpairedASS(t *testing.T) {
+	sub := &Subtitle{Format: FormatASS, Events: []Event{
+		{Start: 1 * time.Second, End: 2 * time.Second, Text: `{\i1}[Risas]{\i0}\NJUAN: Hola`},
+		{Start: 1 * time.Second, End: 2 * time.Second, Text: `{\pos(960,100)}Tienda`},
+	}}
+
+	sub.StripHearingImpaired()
+	if sub.Events[0].Text != "Hola" || sub.Events[1].Text != `{\pos(960,100)}Tienda` {
+		t.Errorf("unexpected events: %q, %q", sub.Events[0].Text, sub.Events[1].Text)
+	}
+}
+
+func TestRetime(t *testing.T) {
+	sub := &Subtitle{Format: FormatSRT, Events: []Event{
+		{Start: 1 * time.Second, End: 2 * time.Second, Text: "uno"},
+		{Start: 3 * time.Second, End: 5 * time.Second, Text: "dos"},
+		{Start: 10 * time.Second, End: 12 * time.Second, Text: "tres"},
+	}}
+
+	sub.Retime(2, -7*time.Second)
+	if len(sub.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(sub.Events))
+	}
+	if e := sub.Events[0]; e.Start != 0 || e.End != 3*time.Second {
+		t.Errorf("unexpected cut event: %v --> %v", e.Start, e.End)
+	}
+	if e := sub.Events[1]; e.Start != 13*time.Second || e.End != 17*time.Second {
+		t.Errorf("unexpected event: %v --> %v", e.Start, e.End)
+	}
+}
+
+func TestFonts(t *testing.T) {
+	sub, err := Parse([]byte(assSample+"\n[Events]\nDialogue: 0,0:00:07.00,0:00:08.00,Default,,0,0,0,,{\\fn@MS Gothic}縦{\\fnopen sans semibold\\rSign}Texto\n"), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fonts := sub.Fonts()
+	if want := []string{"Open Sans Semibold", "Arial", "MS Gothic"}; strings.Join(fonts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected fonts %v, got %v", want, fonts)
+	}
+}
+
+func TestFontChars(t *testing.T) {
+	sub, err := Parse([]byte(assSample+"\n[Events]\nDialogue: 0,0:00:07.00,0:00:08.00,Default,,0,0,0,,{\\fn@MS Gothic}縦{\\rSign}xo\\hz\n"), FormatASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chars := sub.FontChars()
+	for font, want := range map[string]string{
+		"open sans semibold": " ,?BHacegilnoqrstu¿é",
+		"arial":              "Tadeinoxz ",
+		"ms gothic":          "縦",
+	} {
+		if got := string(chars[font]); got != want {
+			t.Errorf("expected characters %q for %s, got %q", want, font, got)
+		}
+	}
+	if len(chars) != 3 {
+		t.Errorf("expected 3 fonts, got %d", len(chars))
+	}
+}
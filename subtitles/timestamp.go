@@ -0,0 +1,72 @@
+package subtitles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTimestamp reads the timestamps of every supported format: "01:02:03,456" (SRT),
+// "01:02:03.456" or "02:03.456" (WebVTT) and "1:02:03.45" (ASS).
+func ParseTimestamp(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	seconds, fraction := value, ""
+	if idx := strings.LastIndexAny(value, ".,"); idx != -1 {
+		seconds, fraction = value[:idx], value[idx+1:]
+	}
+
+	parts := strings.Split(seconds, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %s", value)
+	}
+
+	var total time.Duration
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		total = total*60 + time.Duration(n)*time.Second
+	}
+
+	if fraction != "" {
+		if len(fraction) > 3 {
+			fraction = fraction[:3]
+		}
+		n, err := strconv.Atoi(fraction)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		for i := len(fraction); i < 3; i++ {
+			n *= 10
+		}
+		total += time.Duration(n) * time.Millisecond
+	}
+
+	return total, nil
+}
+
+func splitDuration(d time.Duration) (hours, minutes, seconds, millis int64) {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return ms / 3600000, ms / 60000 % 60, ms / 1000 % 60, ms % 1000
+}
+
+func formatSRTTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+func formatWebVTTTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+// formatASSTimestamp writes centiseconds, the precision of the format, rounding to the nearest one.
+func formatASSTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d.Round(10 * time.Millisecond))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", h, m, s, ms/10)
+}
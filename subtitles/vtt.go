@@ -0,0 +1,51 @@
+package subtitles
+
+import (
+	"fmt"
+	"strings"
+)
+
+func parseWebVTT(text string) (*Subtitle, error) {
+	blocks := splitBlocks(text)
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+
+	sub := &Subtitle{Format: FormatWebVTT}
+	for _, block := range blocks[1:] {
+		if strings.HasPrefix(block, "NOTE") || strings.HasPrefix(block, "STYLE") || strings.HasPrefix(block, "REGION") {
+			continue
+		}
+
+		lines := strings.Split(block, "\n")
+		// Cues may have an identifier before the timing line
+		if !strings.Contains(lines[0], "-->") && len(lines) > 1 {
+			lines = lines[1:]
+		}
+		if !strings.Contains(lines[0], "-->") {
+			continue
+		}
+
+		start, end, _, err := parseTiming(lines[0])
+		if err != nil {
+			return nil, fmt.Errorf("cue %d: %v", len(sub.Events)+1, err)
+		}
+
+		sub.Events = append(sub.Events, Event{
+			Start: start,
+			End:   end,
+			Text:  strings.Join(lines[1:], "\n"),
+		})
+	}
+
+	return sub, nil
+}
+
+func writeWebVTT(s *Subtitle) []byte {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for _, e := range s.sortedEvents() {
+		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n", formatWebVTTTimestamp(e.Start), formatWebVTTTimestamp(e.End), e.Text)
+	}
+	return []byte(sb.String())
+}
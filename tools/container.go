@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ContainerConfig describes a container image providing some of the tools, for hosts where they
+// aren't installed.
+type ContainerConfig struct {
+	Runtime string   `json:"runtime"` // docker or podman, docker by default
+	Image   string   `json:"image"`
+	Tools   []string `json:"tools"` // Tools run inside the container, mkvmerge and mkvextract by default
+}
+
+// ContainerExecutor runs the configured tools inside a container with `docker run`. The working
+// directory and the directories of every path in the arguments are mounted at the same location,
+// so the paths stay valid inside the container. Other tools are run by Next.
+type ContainerExecutor struct {
+	Config ContainerConfig
+	Next   Executor
+}
+
+func NewContainerExecutor(cfg ContainerConfig, next Executor) *ContainerExecutor {
+	if cfg.Runtime == "" {
+		cfg.Runtime = "docker"
+	}
+	if len(cfg.Tools) == 0 {
+		cfg.Tools = []string{MKVMerge, MKVExtract}
+	}
+	return &ContainerExecutor{Config: cfg, Next: next}
+}
+
+func (c *ContainerExecutor) Handles(tool string) bool {
+	return slices.Contains(c.Config.Tools, tool)
+}
+
+func (c *ContainerExecutor) Run(cmd Command) ([]byte, error) {
+	if !c.Handles(cmd.Tool) {
+		return c.Next.Run(cmd)
+	}
+
+	return LocalExecutor{}.Run(Command{
+		Tool:     c.Config.Runtime,
+		Args:     c.Args(cmd),
+		ReadOnly: cmd.ReadOnly,
+	})
+}
+
+// Args builds the container runtime arguments that run the command.
+func (c *ContainerExecutor) Args(cmd Command) []string {
+	args := []string{"run", "--rm", "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())}
+
+	wd, err := os.Getwd()
+	if err == nil {
+		args = append(args, "--workdir", wd)
+	}
+	for _, dir := range mountDirs(wd, cmd.Args) {
+		args = append(args, "--volume", dir+":"+dir)
+	}
+
+	args = append(args, c.Config.Image, cmd.Tool)
+	return append(args, cmd.Args...)
+}
+
+func (c *ContainerExecutor) Resolve(tool string) (string, error) {
+	if !c.Handles(tool) {
+		return resolveWith(c.Next, tool)
+	}
+	if c.Config.Image == "" {
+		return "", fmt.Errorf("no container image configured for %s", tool)
+	}
+	if _, err := (LocalExecutor{}).Resolve(c.Config.Runtime); err != nil {
+		return "", err
+	}
+	return c.Config.Runtime + ":" + c.Config.Image, nil
+}
+
+// trackPrefix matches the "<id>:" prefix of mkvextract and mkvmerge arguments such as 0:track_0.hevc
+var trackPrefix = regexp.MustCompile(`^\d+:`)
+
+// mountDirs returns the directories to mount for the working directory and every path argument.
+func mountDirs(wd string, args []string) []string {
+	var dirs []string
+	add := func(dir string) {
+		if dir != "" && dir != "/" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	add(wd)
+	for _, arg := range args {
+		arg = trackPrefix.ReplaceAllString(arg, "")
+		if strings.HasPrefix(arg, "-") || !strings.ContainsRune(arg, filepath.Separator) {
+			continue
+		}
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			continue
+		}
+		if inf, err := os.Stat(abs); err == nil && inf.IsDir() {
+			add(abs)
+		} else if inf, err := os.Stat(filepath.Dir(abs)); err == nil && inf.IsDir() {
+			// Output files don't exist yet, but their directory does
+			add(filepath.Dir(abs))
+		}
+	}
+	return dirs
+}
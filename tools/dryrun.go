@@ -0,0 +1,32 @@
+package tools
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// DryRunExecutor logs the commands that would write files instead of running them. Read-only
+// commands are still executed so the rest of the pipeline has real data to work with.
+type DryRunExecutor struct {
+	Next Executor
+}
+
+func (d DryRunExecutor) Run(cmd Command) ([]byte, error) {
+	if cmd.ReadOnly {
+		return d.Next.Run(cmd)
+	}
+
+	log.WithFields(log.Fields{"process": cmd.Tool}).Infof("[dry-run] %s", cmd.String())
+	return nil, nil
+}
+
+func (d DryRunExecutor) Resolve(tool string) (string, error) {
+	return resolveWith(d.Next, tool)
+}
+
+// resolveWith resolves the tool with the executor when it knows how, or in the local PATH otherwise.
+func resolveWith(e Executor, tool string) (string, error) {
+	if r, ok := e.(Resolver); ok {
+		return r.Resolve(tool)
+	}
+	return LocalExecutor{}.Resolve(tool)
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Command is an invocation of an external tool.
+type Command struct {
+	Tool string
+	Args []string
+	// ReadOnly commands only query information and don't write any file
+	ReadOnly bool
+}
+
+func (c Command) String() string {
+	return c.Tool + " " + strings.Join(c.Args, " ")
+}
+
+// ExitError is returned when the tool finishes with a non zero exit code.
+type ExitError struct {
+	Tool   string
+	Code   int
+	Output []byte // Standard error, or standard output when the tool reports errors there
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s: exit status %d", e.Tool, e.Code)
+}
+
+// Executor launches the external tools. It returns the standard output of the command.
+type Executor interface {
+	Run(cmd Command) ([]byte, error)
+}
+
+// Resolver is implemented by executors that know where the tools they launch live.
+type Resolver interface {
+	Resolve(tool string) (string, error)
+}
+
+var current Executor = LocalExecutor{}
+
+// SetExecutor replaces the executor used for every external tool invocation.
+func SetExecutor(e Executor) {
+	current = e
+}
+
+func CurrentExecutor() Executor {
+	return current
+}
+
+// Run executes the command with the current executor.
+func Run(tool string, args ...string) ([]byte, error) {
+	return current.Run(Command{Tool: tool, Args: args})
+}
+
+// Query executes a read-only command with the current executor.
+func Query(tool string, args ...string) ([]byte, error) {
+	return current.Run(Command{Tool: tool, Args: args, ReadOnly: true})
+}
+
+// LocalExecutor runs the tools as local processes.
+type LocalExecutor struct{}
+
+func (LocalExecutor) Run(cmd Command) ([]byte, error) {
+	out, err := exec.Command(Path(cmd.Tool), cmd.Args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			output := exitErr.Stderr
+			if len(output) == 0 {
+				output = out
+			}
+			return out, &ExitError{Tool: cmd.Tool, Code: exitErr.ExitCode(), Output: output}
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+func (LocalExecutor) Resolve(tool string) (string, error) {
+	return exec.LookPath(Path(tool))
+}
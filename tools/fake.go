@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Recording is a command captured by a RecordingExecutor, together with its result.
+type Recording struct {
+	Tool     string   `json:"tool"`
+	Args     []string `json:"args"`
+	Output   string   `json:"output"`
+	ExitCode int      `json:"exit_code"`
+}
+
+func (r *Recording) matches(cmd Command) bool {
+	return r.Tool == cmd.Tool && slices.Equal(r.Args, cmd.Args)
+}
+
+// RecordingExecutor runs the commands with another executor and keeps their results, so they can
+// be saved and replayed later in tests.
+type RecordingExecutor struct {
+	Next       Executor
+	mu         sync.Mutex
+	Recordings []Recording
+}
+
+func (r *RecordingExecutor) Run(cmd Command) ([]byte, error) {
+	out, err := r.Next.Run(cmd)
+
+	rec := Recording{Tool: cmd.Tool, Args: cmd.Args, Output: string(out)}
+	if exitErr, ok := err.(*ExitError); ok {
+		rec.ExitCode = exitErr.Code
+		if len(out) == 0 {
+			rec.Output = string(exitErr.Output)
+		}
+	}
+
+	r.mu.Lock()
+	r.Recordings = append(r.Recordings, rec)
+	r.mu.Unlock()
+	return out, err
+}
+
+func (r *RecordingExecutor) Resolve(tool string) (string, error) {
+	return resolveWith(r.Next, tool)
+}
+
+// Save writes the recordings as JSON.
+func (r *RecordingExecutor) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.Recordings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReplayExecutor answers the commands with previous recordings instead of running them, and keeps
+// the list of commands received. Commands without a recording fail.
+type ReplayExecutor struct {
+	mu         sync.Mutex
+	Recordings []Recording
+	Calls      []Command
+}
+
+// LoadReplay reads recordings saved by a RecordingExecutor.
+func LoadReplay(path string) (*ReplayExecutor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recordings []Recording
+	if err := json.Unmarshal(data, &recordings); err != nil {
+		return nil, fmt.Errorf("error parsing recordings %s: %v", path, err)
+	}
+	return &ReplayExecutor{Recordings: recordings}, nil
+}
+
+// On adds a canned answer for the command.
+func (r *ReplayExecutor) On(tool string, args []string, output string, exitCode int) *ReplayExecutor {
+	r.Recordings = append(r.Recordings, Recording{Tool: tool, Args: args, Output: output, ExitCode: exitCode})
+	return r
+}
+
+func (r *ReplayExecutor) Run(cmd Command) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Calls = append(r.Calls, cmd)
+	for _, rec := range r.Recordings {
+		if rec.matches(cmd) {
+			if rec.ExitCode != 0 {
+				return []byte(rec.Output), &ExitError{Tool: cmd.Tool, Code: rec.ExitCode, Output: []byte(rec.Output)}
+			}
+			return []byte(rec.Output), nil
+		}
+	}
+	return nil, fmt.Errorf("no recording for command: %s", cmd.String())
+}
+
+func (r *ReplayExecutor) Resolve(tool string) (string, error) {
+	return tool, nil
+}
@@ -0,0 +1,70 @@
+package tools
+
+// Requirement is the minimum version of a tool needed by a feature. Optional requirements only
+// disable the feature, the rest prevent the program from running.
+type Requirement struct {
+	Tool     string
+	Min      Version
+	Feature  string
+	Optional bool
+}
+
+var Requirements = []Requirement{
+	{Tool: MKVMerge, Min: Version{51, 0, 0}, Feature: "language_ietf in mkvmerge -J"},
+	{Tool: MKVMerge, Min: Version{57, 0, 0}, Feature: "--original-flag and the other track flags"},
+	{Tool: MKVExtract, Min: Version{17, 0, 0}, Feature: "mkvextract <file> <mode> syntax"},
+	{Tool: FFmpeg, Min: Version{5, 1, 0}, Feature: "-fps_mode for video transcoding", Optional: true},
+	{Tool: FFmpeg, Min: Version{7, 0, 0}, Feature: "HDR metadata forwarding to libx265/libsvtav1", Optional: true},
+}
+
+type Problem struct {
+	Requirement
+	Found   Version
+	Missing bool // The tool couldn't be located
+}
+
+// Status is the result of locating every tool and checking the requirements.
+type Status struct {
+	Tools    map[string]*Tool
+	Errors   map[string]error
+	Problems []Problem
+}
+
+// Check locates the tools and verifies the version requirements. Tools with unknown versions are
+// assumed to satisfy them.
+func Check() *Status {
+	status := &Status{
+		Tools:  make(map[string]*Tool),
+		Errors: make(map[string]error),
+	}
+
+	for _, name := range All {
+		tool, err := Locate(name)
+		if err != nil {
+			status.Errors[name] = err
+		} else {
+			status.Tools[name] = tool
+		}
+	}
+
+	for _, req := range Requirements {
+		tool, ok := status.Tools[req.Tool]
+		if !ok {
+			status.Problems = append(status.Problems, Problem{Requirement: req, Missing: true})
+		} else if tool.Version.Known() && !tool.Version.AtLeast(req.Min) {
+			status.Problems = append(status.Problems, Problem{Requirement: req, Found: tool.Version})
+		}
+	}
+
+	return status
+}
+
+// Fatal reports whether any required feature can't be used.
+func (s *Status) Fatal() bool {
+	for _, p := range s.Problems {
+		if !p.Optional {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	MKVMerge   = "mkvmerge"
+	MKVExtract = "mkvextract"
+	FFmpeg     = "ffmpeg"
+)
+
+// All lists every external tool used by the program.
+var All = []string{MKVMerge, MKVExtract, FFmpeg}
+
+// versionArgs are the arguments each tool needs to print its version.
+var versionArgs = map[string][]string{
+	MKVMerge:   {"--version"},
+	MKVExtract: {"--version"},
+	FFmpeg:     {"-hide_banner", "-version"},
+}
+
+// configured holds the paths set in the configuration file
+var configured = map[string]string{}
+
+type Tool struct {
+	Name        string
+	Path        string
+	Version     Version
+	VersionText string // First line of the version output
+}
+
+// Configure sets the paths of the tools given in the configuration, keyed by tool name.
+func Configure(paths map[string]string) {
+	configured = make(map[string]string, len(paths))
+	for name, p := range paths {
+		configured[name] = p
+	}
+}
+
+// EnvVar returns the environment variable that overrides the path of the tool, e.g. VIDEOREPACK_MKVMERGE.
+func EnvVar(name string) string {
+	return "VIDEOREPACK_" + strings.ToUpper(name)
+}
+
+// Path returns the command used to launch the tool. The environment variable takes precedence over
+// the configuration file; without overrides the tool is looked up in PATH.
+func Path(name string) string {
+	if p := os.Getenv(EnvVar(name)); p != "" {
+		return p
+	}
+	if p, ok := configured[name]; ok && p != "" {
+		return p
+	}
+	return name
+}
+
+// Locate finds the tool binary and queries its version.
+func Locate(name string) (*Tool, error) {
+	p, err := resolveWith(current, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found: %v", name, err)
+	}
+
+	out, err := Query(name, versionArgs[name]...)
+	if err != nil {
+		return nil, fmt.Errorf("%s version error: %v", name, err)
+	}
+
+	text := strings.TrimSpace(string(out))
+	if idx := strings.IndexByte(text, '\n'); idx != -1 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	return &Tool{
+		Name:        name,
+		Path:        p,
+		Version:     ParseVersion(text),
+		VersionText: text,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Version is a release number of an external tool. The zero value means the version is unknown,
+// as happens with ffmpeg builds from git.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+var versionPattern = regexp.MustCompile(`(?:^|[\s(])[vn]?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion extracts the first release number of a version banner, such as
+// "mkvmerge v81.0 ('Milliontown') 64-bit" or "ffmpeg version 6.1.1-3ubuntu5 Copyright ...".
+func ParseVersion(text string) Version {
+	matches := versionPattern.FindStringSubmatch(text)
+	if len(matches) != 4 {
+		return Version{}
+	}
+
+	var v Version
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	if matches[3] != "" {
+		v.Patch, _ = strconv.Atoi(matches[3])
+	}
+	return v
+}
+
+func (v Version) Known() bool {
+	return v != Version{}
+}
+
+// AtLeast reports whether the version is equal or newer than min.
+func (v Version) AtLeast(min Version) bool {
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch >= min.Patch
+}
+
+func (v Version) String() string {
+	if !v.Known() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
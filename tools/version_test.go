@@ -0,0 +1,32 @@
+package tools
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		text string
+		want Version
+	}{
+		{"mkvmerge v81.0 ('Milliontown') 64-bit", Version{81, 0, 0}},
+		{"mkvextract v57.0.0 ('Till The End') 64-bit", Version{57, 0, 0}},
+		{"ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 the FFmpeg developers", Version{6, 1, 1}},
+		{"ffmpeg version n7.0.2 Copyright (c) 2000-2024 the FFmpeg developers", Version{7, 0, 2}},
+		{"ffmpeg version N-113000-g2f3b8e1 Copyright (c) 2000-2024 the FFmpeg developers", Version{}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseVersion(tt.text); got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	min := Version{57, 0, 0}
+	if !(Version{57, 0, 0}).AtLeast(min) || !(Version{81, 0, 0}).AtLeast(min) {
+		t.Error("newer or equal versions must satisfy the minimum")
+	}
+	if (Version{56, 9, 9}).AtLeast(min) {
+		t.Error("older versions must not satisfy the minimum")
+	}
+}
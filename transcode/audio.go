@@ -0,0 +1,111 @@
+package transcode
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"videorepack/ffmpeg"
+	"videorepack/mkv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// audioEncoders maps the supported audio encoders to the codec ID of their output.
+var audioEncoders = map[string]string{
+	ffmpeg.EncoderEAC3: "A_EAC3",
+	ffmpeg.EncoderAC3:  "A_AC3",
+	ffmpeg.EncoderAAC:  "A_AAC",
+	ffmpeg.EncoderOpus: "A_OPUS",
+	ffmpeg.EncoderFLAC: "A_FLAC",
+}
+
+// AudioRule converts the audio tracks of the given codecs or codec classes with the first
+// available encoder of a fallback chain, e.g. eac3 → ac3 → aac.
+type AudioRule struct {
+	Codecs   []string         `json:"codecs"`   // Matroska codec IDs, e.g. A_FLAC or A_PCM
+	Classes  []mkv.CodecClass `json:"classes"`  // Codec classes, e.g. lossless
+	Profiles []string         `json:"profiles"` // Optional codec profiles the track must have, e.g. HE-AAC
+	Channels []string         `json:"channels"` // Optional channel layouts the track must have, e.g. 2.0
+	Encoders []string         `json:"encoders"` // Fallback chain of ffmpeg encoders
+	Bitrate  string           `json:"bitrate"`  // Optional target bitrate, e.g. 640k
+}
+
+// DefaultAudioRules returns the built-in rule that converts FLAC to E-AC-3, AC-3 or AAC.
+func DefaultAudioRules() []AudioRule {
+	return []AudioRule{{
+		Codecs:   []string{"A_FLAC"},
+		Encoders: []string{ffmpeg.EncoderEAC3, ffmpeg.EncoderAC3, ffmpeg.EncoderAAC},
+	}}
+}
+
+func (r *AudioRule) Matches(t *mkv.ExtractedTrack) bool {
+	return t.Info.Type == "audio" && matchesCodec(t, r.Codecs, r.Classes) && matchesProfile(t, r.Profiles) &&
+		(len(r.Channels) == 0 || slices.Contains(r.Channels, t.Info.ChannelLayout()))
+}
+
+func (r *AudioRule) String() string {
+	return fmt.Sprintf("%s → %s", codecList(r.Codecs, r.Classes), strings.Join(r.Encoders, " → "))
+}
+
+// matchesCodec reports whether the track codec ID starts with one of the codecs or its codec is of
+// one of the classes.
+func matchesCodec(t *mkv.ExtractedTrack, codecs []string, classes []mkv.CodecClass) bool {
+	return slices.ContainsFunc(codecs, func(codec string) bool {
+		return strings.HasPrefix(t.Info.Properties.CodecID, codec)
+	}) || slices.Contains(classes, t.Info.Properties.Codec().Class)
+}
+
+// matchesProfile reports whether the codec profile of the track is one of the profiles. Any track
+// matches an empty list.
+func matchesProfile(t *mkv.ExtractedTrack, profiles []string) bool {
+	if len(profiles) == 0 {
+		return true
+	}
+	details, err := t.Info.Properties.CodecDetails()
+	return err == nil && slices.ContainsFunc(profiles, func(profile string) bool {
+		return strings.EqualFold(profile, details.Profile)
+	})
+}
+
+func codecList(codecs []string, classes []mkv.CodecClass) string {
+	list := slices.Clone(codecs)
+	for _, class := range classes {
+		list = append(list, string(class))
+	}
+	return strings.Join(list, ", ")
+}
+
+// MatchAudioRule returns the first rule that matches the track, or nil if none does.
+func MatchAudioRule(rules []AudioRule, t *mkv.ExtractedTrack) *AudioRule {
+	for i := range rules {
+		if rules[i].Matches(t) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Audio converts the audio track with the given encoder and replaces its extracted file.
+func Audio(t *mkv.ExtractedTrack, encoder string, bitrate string) (string, error) {
+	codecID, ok := audioEncoders[encoder]
+	if !ok {
+		return "", fmt.Errorf("unsupported audio encoder: %s", encoder)
+	}
+	codec, _ := mkv.Codecs.Lookup(codecID)
+
+	targetFilePath := t.FilePath + "." + codec.Extension
+	cmd := ffmpeg.NewCommand(targetFilePath)
+	cmd.Map(cmd.Input(t.FilePath).Stream(ffmpeg.StreamAudio, 0))
+	stream := cmd.Stream(ffmpeg.StreamAudio, -1).Codec(encoder)
+	if bitrate != "" {
+		stream.Bitrate(bitrate)
+	}
+
+	log.Debugf("Converting audio track %d from %s with %s", t.Info.ID, t.Info.Properties.CodecID, encoder)
+	if err := ffmpeg.Run(cmd); err != nil {
+		return "", err
+	}
+
+	t.Replace(targetFilePath, codecID)
+	return targetFilePath, nil
+}
@@ -0,0 +1,53 @@
+package transcode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IsTextSubtitle reports whether the track is a subtitle track stored as text.
+func IsTextSubtitle(t *mkv.ExtractedTrack) bool {
+	if t.Info.Type != "subtitles" {
+		return false
+	}
+	_, ok := subtitles.FormatFromCodecID(t.Info.Properties.CodecID)
+	return ok || t.Info.Properties.TextSubtitles
+}
+
+// NormalizeEncoding converts a text subtitle track to UTF-8. The encoding is detected from the
+// content, using the Encoding property of the track as a hint and fallback for 8-bit data that
+// doesn't look Western. It returns the detected encoding and the new file, which is empty when the
+// track was already UTF-8.
+func NormalizeEncoding(t *mkv.ExtractedTrack, fallback string) (string, string, error) {
+	data, err := os.ReadFile(t.FilePath)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading subtitles: %v", err)
+	}
+
+	encoding := subtitles.DetectEncoding(data, t.Info.Properties.Encoding, fallback)
+	if encoding == subtitles.EncodingUTF8 {
+		t.Info.Properties.Encoding = subtitles.EncodingUTF8
+		return encoding, "", nil
+	}
+
+	log.Debugf("Converting subtitle track %d from %s to UTF-8", t.Info.ID, encoding)
+	decoded, err := subtitles.DecodeToUTF8(data, encoding)
+	if err != nil {
+		return encoding, "", err
+	}
+
+	// Keep the extension, mkvmerge uses it to recognize sidecar files
+	targetFilePath := t.FilePath + ".utf8" + filepath.Ext(t.FilePath)
+	if err := os.WriteFile(targetFilePath, decoded, 0644); err != nil {
+		return encoding, "", err
+	}
+
+	t.FilePath = targetFilePath
+	t.Info.Properties.Encoding = subtitles.EncodingUTF8
+	return encoding, targetFilePath, nil
+}
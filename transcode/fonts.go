@@ -0,0 +1,42 @@
+package transcode
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"videorepack/fonts"
+	"videorepack/mkv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SubsetFont rewrites a font attachment with only the glyphs of the given characters and replaces
+// its file, keeping the attachment name. It returns the new file and the sizes before and after.
+func SubsetFont(a *mkv.ExtractedAttachment, chars []rune) (string, int, int, error) {
+	data, err := os.ReadFile(a.FilePath)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	chars = slices.Clone(chars)
+	slices.Sort(chars)
+	subset, err := fonts.Subset(data, slices.Compact(chars))
+	if err != nil {
+		return "", len(data), 0, err
+	}
+
+	// Fonts may come from the fonts directory, the subset is written to the temp directory
+	target, err := os.CreateTemp("", "videorepack_font_*"+filepath.Ext(a.FilePath))
+	if err != nil {
+		return "", len(data), 0, err
+	}
+	defer target.Close()
+	if _, err := target.Write(subset); err != nil {
+		os.Remove(target.Name())
+		return "", len(data), 0, err
+	}
+
+	log.Debugf("Subset font %s to %d characters: %d -> %d bytes", a.Info.FileName, len(chars), len(data), len(subset))
+	a.FilePath = target.Name()
+	return target.Name(), len(data), len(subset), nil
+}
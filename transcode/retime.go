@@ -0,0 +1,135 @@
+package transcode
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RetimeRule fixes the timing of the tracks of some files, e.g. subtitles timed for the 25 fps PAL
+// release of a show on a 23.976 fps video. The framerates and the offset can be combined; two sync
+// points replace both.
+type RetimeRule struct {
+	Files     string   `json:"files"`     // Glob matched against the input file name, empty matches every file
+	Types     []string `json:"types"`     // Track types (audio, subtitles), empty matches every type but video
+	Languages []string `json:"languages"` // Track languages, empty matches every language
+	Tracks    []int    `json:"tracks"`    // Track IDs, empty matches every track
+
+	Offset  int64       `json:"offset"`   // in milliseconds
+	FromFPS float64     `json:"from_fps"` // Framerate the track was timed for
+	ToFPS   float64     `json:"to_fps"`   // Framerate of the video
+	Sync    []SyncPoint `json:"sync"`
+}
+
+// SyncPoint maps a timestamp of the track to the one where it should be shown. Timestamps are
+// written like in subtitles, e.g. "00:01:02.500".
+type SyncPoint struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (r *RetimeRule) Matches(file string, t *mkv.ExtractedTrack) bool {
+	if r.Files != "" {
+		if ok, _ := filepath.Match(r.Files, file); !ok {
+			return false
+		}
+	}
+	if len(r.Types) > 0 && !slices.Contains(r.Types, t.Info.Type) || len(r.Types) == 0 && t.Info.Type == "video" {
+		return false
+	}
+	if len(r.Tracks) > 0 && !slices.Contains(r.Tracks, t.Info.ID) {
+		return false
+	}
+	if len(r.Languages) > 0 && !slices.ContainsFunc(r.Languages, func(lang string) bool {
+		tag, err := mkv.FromIETFName(lang)
+		return err == nil && tag == t.Info.Properties.LanguageIETF
+	}) {
+		return false
+	}
+	return true
+}
+
+// Retiming returns the factor the timestamps are multiplied by and the offset added afterwards.
+func (r *RetimeRule) Retiming() (float64, time.Duration, error) {
+	if len(r.Sync) > 0 {
+		if len(r.Sync) != 2 {
+			return 0, 0, fmt.Errorf("two sync points are needed, found %d", len(r.Sync))
+		}
+
+		var points [2][2]time.Duration
+		for i, p := range r.Sync {
+			var err error
+			if points[i][0], err = subtitles.ParseTimestamp(p.From); err != nil {
+				return 0, 0, err
+			}
+			if points[i][1], err = subtitles.ParseTimestamp(p.To); err != nil {
+				return 0, 0, err
+			}
+		}
+		if points[0][0] == points[1][0] {
+			return 0, 0, fmt.Errorf("sync points must be different")
+		}
+
+		factor := float64(points[1][1]-points[0][1]) / float64(points[1][0]-points[0][0])
+		offset := points[0][1] - time.Duration(float64(points[0][0])*factor)
+		return factor, offset, nil
+	}
+
+	factor := 1.0
+	if r.FromFPS > 0 && r.ToFPS > 0 {
+		factor = r.FromFPS / r.ToFPS
+	} else if r.FromFPS > 0 || r.ToFPS > 0 {
+		return 0, 0, fmt.Errorf("both from_fps and to_fps are needed")
+	}
+	return factor, time.Duration(r.Offset) * time.Millisecond, nil
+}
+
+// Apply sets the retiming of the rule in the track operations.
+func (r *RetimeRule) Apply(t *mkv.ExtractedTrack) error {
+	factor, offset, err := r.Retiming()
+	if err != nil {
+		return err
+	}
+
+	t.Operations.Delay = offset.Milliseconds()
+	t.Operations.Stretch = 0
+	if factor != 1 {
+		t.Operations.Stretch = factor
+	}
+	return nil
+}
+
+// MatchRetimeRule returns the first rule that matches the track of the given file, or nil if none does.
+func MatchRetimeRule(rules []RetimeRule, file string, t *mkv.ExtractedTrack) *RetimeRule {
+	for i := range rules {
+		if rules[i].Matches(file, t) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Retime applies the delay and stretch operations of a text subtitle track to its events, so
+// mkvmerge doesn't have to adjust the timestamps.
+func Retime(t *mkv.ExtractedTrack) (string, error) {
+	sub, err := subtitles.ParseFile(t.FilePath)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Retiming subtitle track %d (delay %d ms, stretch %v)", t.Info.ID, t.Operations.Delay, t.Operations.Stretch)
+	sub.Retime(t.Operations.Stretch, time.Duration(t.Operations.Delay)*time.Millisecond)
+
+	targetFilePath, err := replaceSubtitle(t, sub, "retimed")
+	if err != nil {
+		return "", err
+	}
+	t.Operations.Delay = 0
+	t.Operations.Stretch = 0
+	return targetFilePath, nil
+}
@@ -0,0 +1,68 @@
+package transcode
+
+import (
+	"math"
+	"testing"
+	"time"
+	"videorepack/mkv"
+)
+
+func TestRetimeRuleRetiming(t *testing.T) {
+	tests := []struct {
+		rule   RetimeRule
+		factor float64
+		offset time.Duration
+	}{
+		{RetimeRule{Offset: -1500}, 1, -1500 * time.Millisecond},
+		{RetimeRule{FromFPS: 25, ToFPS: 23.976, Offset: 200}, 25 / 23.976, 200 * time.Millisecond},
+		{RetimeRule{Sync: []SyncPoint{{"00:01:00,000", "00:01:02,000"}, {"00:21:00,000", "00:21:52,000"}}}, 1.0416666, -500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		factor, offset, err := tt.rule.Retiming()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(factor-tt.factor) > 1e-6 || (offset-tt.offset).Abs() > time.Millisecond {
+			t.Errorf("%+v: expected %v, %v, got %v, %v", tt.rule, tt.factor, tt.offset, factor, offset)
+		}
+	}
+
+	for _, rule := range []RetimeRule{
+		{Sync: []SyncPoint{{"00:01:00,000", "00:01:02,000"}}},
+		{Sync: []SyncPoint{{"00:01:00,000", "00:01:02,000"}, {"00:01:00,000", "00:01:05,000"}}},
+		{FromFPS: 25},
+	} {
+		if _, _, err := rule.Retiming(); err == nil {
+			t.Errorf("%+v: expected an error", rule)
+		}
+	}
+}
+
+func TestMatchRetimeRule(t *testing.T) {
+	spanish, _ := mkv.FromIETFName("es-ES")
+	subtitle := &mkv.ExtractedTrack{Info: mkv.Track{ID: 3, Type: "subtitles", Properties: mkv.TrackProperties{LanguageIETF: spanish}}}
+	video := &mkv.ExtractedTrack{Info: mkv.Track{ID: 0, Type: "video"}}
+
+	rules := []RetimeRule{
+		{Files: "Show S01E02*", Offset: 100},
+		{Files: "Show S01E*", Languages: []string{"es-ES"}, Types: []string{"subtitles"}, Offset: 200},
+	}
+
+	if rule := MatchRetimeRule(rules, "Show S01E02.mkv", subtitle); rule != &rules[0] {
+		t.Errorf("expected the episode rule, got %+v", rule)
+	}
+	if rule := MatchRetimeRule(rules, "Show S01E03.mkv", subtitle); rule != &rules[1] {
+		t.Errorf("expected the show rule, got %+v", rule)
+	}
+	if rule := MatchRetimeRule(rules, "Show S01E02.mkv", video); rule != nil {
+		t.Errorf("video tracks need an explicit type, got %+v", rule)
+	}
+	if rule := MatchRetimeRule(rules, "Other S01E03.mkv", subtitle); rule != nil {
+		t.Errorf("expected no rule, got %+v", rule)
+	}
+
+	if err := rules[0].Apply(subtitle); err != nil || subtitle.Operations.Delay != 100 || subtitle.Operations.Stretch != 0 {
+		t.Errorf("unexpected operations: %+v (%v)", subtitle.Operations, err)
+	}
+}
@@ -0,0 +1,101 @@
+package transcode
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"videorepack/mkv"
+	"videorepack/subtitles"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SubtitleRule converts the text subtitle tracks of the given codecs to another format, e.g. ASS to
+// SRT for players that render ASS badly.
+type SubtitleRule struct {
+	Codecs []string         `json:"codecs"` // Matroska codec IDs, e.g. S_TEXT/ASS
+	To     subtitles.Format `json:"to"`     // srt, ass or vtt
+}
+
+func (r *SubtitleRule) Matches(t *mkv.ExtractedTrack) bool {
+	return t.Info.Type == "subtitles" && t.Info.Properties.CodecID != r.To.CodecID() &&
+		slices.Contains(r.Codecs, t.Info.Properties.CodecID)
+}
+
+func (r *SubtitleRule) String() string {
+	return fmt.Sprintf("%s → %s", strings.Join(r.Codecs, ", "), r.To.CodecID())
+}
+
+// MatchSubtitleRule returns the first rule that matches the track, or nil if none does.
+func MatchSubtitleRule(rules []SubtitleRule, t *mkv.ExtractedTrack) *SubtitleRule {
+	for i := range rules {
+		if rules[i].Matches(t) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Subtitle converts a text subtitle track to the given format and replaces its extracted file.
+// The style is used for every line when converting to ASS.
+func Subtitle(t *mkv.ExtractedTrack, to subtitles.Format, style subtitles.Style) (string, error) {
+	if to.CodecID() == "" {
+		return "", fmt.Errorf("unsupported subtitle format: %s", to)
+	}
+
+	sub, err := subtitles.ParseFile(t.FilePath)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Converting subtitle track %d from %s to %s (%d lines)", t.Info.ID, sub.Format, to, len(sub.Events))
+	sub.ConvertTo(to, style)
+
+	targetFilePath := t.FilePath + "." + string(sub.Format)
+	if err := sub.WriteFile(targetFilePath); err != nil {
+		return "", err
+	}
+
+	t.Replace(targetFilePath, sub.Format.CodecID())
+	t.Info.Properties.Encoding = "UTF-8"
+	return targetFilePath, nil
+}
+
+// StripHearingImpaired writes a copy of an SDH text subtitle track without sound descriptions,
+// speaker labels and music notes, and returns it as a new track with the given ID.
+func StripHearingImpaired(t *mkv.ExtractedTrack, id int) (mkv.ExtractedTrack, error) {
+	sub, err := subtitles.ParseFile(t.FilePath)
+	if err != nil {
+		return mkv.ExtractedTrack{}, err
+	}
+
+	changed := sub.StripHearingImpaired()
+	log.Debugf("Stripped hearing impaired cues from %d lines of subtitle track %d", changed, t.Info.ID)
+
+	stripped := *t
+	stripped.Info.ID = id
+	stripped.Info.Properties.FlagHearingImpaired = false
+	stripped.Info.Properties.DefaultTrack = false
+	stripped.Sidecar = ""
+	if _, err := replaceSubtitle(&stripped, sub, "nosdh"); err != nil {
+		return mkv.ExtractedTrack{}, err
+	}
+	return stripped, nil
+}
+
+// replaceSubtitle writes an edited subtitle next to the extracted file of the track and replaces it.
+func replaceSubtitle(t *mkv.ExtractedTrack, sub *subtitles.Subtitle, suffix string) (string, error) {
+	if sub.Format == subtitles.FormatSSA {
+		// SSA is always written with the v4+ styles
+		sub.Format = subtitles.FormatASS
+	}
+
+	targetFilePath := t.FilePath + "." + suffix + "." + string(sub.Format)
+	if err := sub.WriteFile(targetFilePath); err != nil {
+		return "", err
+	}
+
+	t.Replace(targetFilePath, sub.Format.CodecID())
+	t.Info.Properties.Encoding = "UTF-8"
+	return targetFilePath, nil
+}
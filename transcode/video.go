@@ -0,0 +1,135 @@
+package transcode
+
+import (
+	"fmt"
+	"slices"
+	"time"
+	"videorepack/ffmpeg"
+	"videorepack/mkv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// VideoProfile describes how a video track is re-encoded with a software encoder.
+type VideoProfile struct {
+	Encoder     string `json:"encoder"` // libx265 or libsvtav1
+	CRF         int    `json:"crf"`
+	Preset      string `json:"preset"`
+	PixelFormat string `json:"pixel_format"`
+	// Extra encoder private parameters (x265-params / svtav1-params syntax)
+	EncoderParams string `json:"encoder_params"`
+}
+
+// VideoRule selects the video tracks a VideoProfile is applied to. The rule matches when the
+// track bitrate is above MinBitrate or its codec is one of Codecs or Classes. When HDR is set, only
+// tracks whose dynamic range formats are all listed match, and likewise for BitDepths and Profiles.
+// Without HDR, Dolby Vision and HDR10+ tracks never match, as their dynamic metadata is dropped.
+type VideoRule struct {
+	MinBitrate int64            `json:"min_bitrate"` // bits per second, zero disables the check
+	Codecs     []string         `json:"codecs"`      // Matroska codec IDs, e.g. V_MPEG2 or V_MS/VFW/FOURCC
+	Classes    []mkv.CodecClass `json:"classes"`     // Codec classes, e.g. lossless
+	HDR        []mkv.HDRFormat  `json:"hdr"`         // SDR, HDR10, HDR10+, HLG or DV
+	BitDepths  []int            `json:"bit_depths"`  // e.g. 8 to leave 10-bit encodes alone
+	Profiles   []string         `json:"profiles"`    // Codec profiles, e.g. High or Main 10
+	Profile    VideoProfile     `json:"profile"`
+}
+
+func (r *VideoRule) Matches(t *mkv.ExtractedTrack, duration time.Duration) bool {
+	if t.Info.Type != "video" {
+		return false
+	}
+	if hdr := t.Info.HDR(); len(r.HDR) > 0 && !hdr.HasOnly(r.HDR) || len(r.HDR) == 0 && hdr.Dynamic() {
+		return false
+	}
+	if len(r.BitDepths) > 0 {
+		if details, err := t.Info.Properties.CodecDetails(); err != nil || !slices.Contains(r.BitDepths, details.BitDepth) {
+			return false
+		}
+	}
+	if !matchesProfile(t, r.Profiles) {
+		return false
+	}
+
+	if matchesCodec(t, r.Codecs, r.Classes) {
+		return true
+	}
+
+	return r.MinBitrate > 0 && t.Bitrate(duration) > r.MinBitrate
+}
+
+// MatchVideoRule returns the first rule that matches the track, or nil if none does.
+func MatchVideoRule(rules []VideoRule, t *mkv.ExtractedTrack, duration time.Duration) *VideoRule {
+	for i := range rules {
+		if rules[i].Matches(t, duration) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Video re-encodes the video track with the given profile and replaces its extracted file.
+// Frames are passed through untouched, so the track TimeMapPath still applies to the new stream.
+// Static HDR metadata (colour description, mastering display and light levels) is forwarded by
+// ffmpeg from the decoded frames to the encoder, dynamic metadata is dropped.
+func Video(t *mkv.ExtractedTrack, profile VideoProfile) (string, error) {
+	hdr := t.Info.HDR()
+	if dv := hdr.DolbyVision; dv != nil {
+		log.Warnf("Video track %d: the Dolby Vision metadata of profile %d is dropped", t.Info.ID, dv.Profile)
+		if dv.Compatibility == 0 {
+			log.Warnf("Video track %d: the Dolby Vision base layer isn't compatible with other players, the colours of the encode will be wrong", t.Info.ID)
+		}
+	}
+	if hdr.HDR10Plus {
+		log.Warnf("Video track %d: the HDR10+ dynamic metadata is dropped", t.Info.ID)
+	}
+
+	var codecID, params string
+	switch profile.Encoder {
+	case ffmpeg.EncoderX265:
+		codecID = "V_MPEGH/ISO/HEVC"
+		// Repeat the parameter sets so every keyframe carries the HDR signalling
+		params = "repeat-headers=1"
+	case ffmpeg.EncoderSVTAV1:
+		codecID = "V_AV1"
+	default:
+		return "", fmt.Errorf("unsupported video encoder: %s", profile.Encoder)
+	}
+	codec, _ := mkv.Codecs.Lookup(codecID)
+
+	if profile.EncoderParams != "" {
+		if params != "" {
+			params += ":"
+		}
+		params += profile.EncoderParams
+	}
+
+	pixelFormat := profile.PixelFormat
+	if pixelFormat == "" {
+		// 10 bits keeps HDR sources intact and also improves the efficiency of SDR encodes
+		pixelFormat = "yuv420p10le"
+	}
+
+	targetFilePath := t.FilePath + "." + codec.Extension
+	log.Debugf("Transcoding video track %d with %s (crf %d, preset %s)", t.Info.ID, profile.Encoder, profile.CRF, profile.Preset)
+	err := ffmpeg.Convert(ffmpeg.ConvertOptions{
+		Inputs: []ffmpeg.InputFile{{
+			Path: t.FilePath,
+		}},
+		OutputPath: targetFilePath,
+		Tracks: []ffmpeg.TrackConvertOptions{{
+			Index:         "v",
+			Encoder:       profile.Encoder,
+			CRF:           profile.CRF,
+			Preset:        profile.Preset,
+			PixelFormat:   pixelFormat,
+			EncoderParams: params,
+		}},
+		PassthroughFrames: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	t.Replace(targetFilePath, codecID)
+	return targetFilePath, nil
+}
@@ -0,0 +1,88 @@
+package transcode
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"videorepack/ffmpeg"
+	"videorepack/mkv"
+	"videorepack/tools"
+	"videorepack/types"
+)
+
+func videoTrack(codecID string, bps int, private []byte) *mkv.ExtractedTrack {
+	t := &mkv.ExtractedTrack{Info: mkv.Track{ID: 0, Type: "video"}}
+	t.Info.Properties.CodecID = codecID
+	t.Info.Properties.TagBps = types.StringInt(bps)
+	t.Info.Properties.CodecPrivateData = private
+	return t
+}
+
+// dolbyVision returns a Dolby Vision configuration record with a base layer and RPU.
+func dolbyVision(profile int, compatibility int) []byte {
+	return []byte{'d', 'v', 'c', 'C', 1, 0, byte(profile << 1), 6<<3 | 5, byte(compatibility << 4)}
+}
+
+func TestMatchVideoRule(t *testing.T) {
+	x265 := VideoProfile{Encoder: ffmpeg.EncoderX265, CRF: 20}
+	rules := []VideoRule{
+		{Codecs: []string{"V_MPEG2"}, Profile: x265},
+		{MinBitrate: 20_000_000, Profile: x265},
+	}
+	withDV := append(slices.Clone(rules), VideoRule{MinBitrate: 20_000_000, HDR: []mkv.HDRFormat{mkv.DolbyVision, mkv.HDR10, mkv.SDR}, Profile: x265})
+
+	hdr10 := videoTrack("V_AV1", 30_000_000, nil)
+	hdr10.Info.Properties.ColorTransferCharacteristics = 16
+	hdr10.Info.Properties.MaxLuminance = 1000
+
+	for name, c := range map[string]struct {
+		rules []VideoRule
+		track *mkv.ExtractedTrack
+		want  int
+	}{
+		"mpeg2":                  {rules, videoTrack("V_MPEG2", 8_000_000, nil), 0},
+		"high bitrate":           {rules, videoTrack("V_MPEG4/ISO/AVC", 30_000_000, nil), 1},
+		"low bitrate":            {rules, videoTrack("V_MPEG4/ISO/AVC", 8_000_000, nil), -1},
+		"static hdr":             {rules, hdr10, 1},
+		"dolby vision":           {rules, videoTrack("V_MPEGH/ISO/HEVC", 30_000_000, dolbyVision(8, 1)), -1},
+		"dolby vision listed":    {withDV, videoTrack("V_MPEGH/ISO/HEVC", 30_000_000, dolbyVision(8, 1)), 2},
+		"dolby vision profile 5": {withDV, videoTrack("V_MPEGH/ISO/HEVC", 30_000_000, dolbyVision(5, 0)), 2},
+	} {
+		rule := MatchVideoRule(c.rules, c.track, 0)
+		if c.want == -1 && rule != nil || c.want != -1 && rule != &c.rules[c.want] {
+			t.Errorf("%s: expected rule %d, got %+v", name, c.want, rule)
+		}
+	}
+}
+
+func TestVideo(t *testing.T) {
+	replay := &tools.ReplayExecutor{}
+	previous := tools.CurrentExecutor()
+	tools.SetExecutor(replay)
+	t.Cleanup(func() { tools.SetExecutor(previous) })
+
+	input := filepath.Join(t.TempDir(), "track_0.h264")
+	if err := os.WriteFile(input, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	track := videoTrack("V_MPEG4/ISO/AVC", 30_000_000, nil)
+	track.FilePath = input
+
+	output := input + ".hevc"
+	replay.On(tools.FFmpeg, []string{"-nostats", "-hide_banner", "-progress", "-", "-i", input,
+		"-c:v", "libx265", "-crf:v", "20", "-preset:v", "slow", "-pix_fmt:v", "yuv420p10le",
+		"-x265-params:v", "repeat-headers=1:aq-mode=3", "-fps_mode", "passthrough", output}, "", 0)
+
+	got, err := Video(track, VideoProfile{Encoder: ffmpeg.EncoderX265, CRF: 20, Preset: "slow", EncoderParams: "aq-mode=3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != output || track.FilePath != output || track.Info.Properties.CodecID != "V_MPEGH/ISO/HEVC" {
+		t.Errorf("unexpected output %s for track %+v", got, track)
+	}
+
+	if _, err := Video(track, VideoProfile{Encoder: "libx264"}); err == nil {
+		t.Error("expected an error for an unsupported encoder")
+	}
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// StringInt is an integer that may come encoded either as a JSON number or as a quoted string,
+// as mkvmerge does with the statistics tags (tag_bps, tag_duration...).
+type StringInt int64
+
+func (s *StringInt) UnmarshalJSON(b []byte) error {
+	var raw json.Number
+	if err := json.Unmarshal(b, &raw); err != nil {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		raw = json.Number(strings.TrimSpace(str))
+	}
+
+	if raw == "" {
+		*s = 0
+		return nil
+	}
+
+	v, err := strconv.ParseInt(raw.String(), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*s = StringInt(v)
+	return nil
+}